@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultAnthropicURL       = "https://api.anthropic.com"
+	defaultAnthropicModel     = "claude-sonnet-4-5"
+	defaultAnthropicMaxTokens = 2048
+	anthropicVersion          = "2023-06-01"
+)
+
+// AnthropicClient talks to the Anthropic Messages API.
+type AnthropicClient struct {
+	apiKey     string
+	apiURL     string
+	model      string
+	maxTokens  int
+	retry      RetryPolicy
+	httpClient *http.Client
+}
+
+type anthropicRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+}
+type anthropicContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	// StopReason is set in the delta of the message_delta event.
+	StopReason string `json:"stop_reason"`
+}
+
+// anthropicRefusal is the stop reason of the answers stopped by the safety filters.
+const anthropicRefusal = "refusal"
+
+type anthropicStreamEvent struct {
+	Type  string           `json:"type"`
+	Delta anthropicContent `json:"delta"`
+	Error ErrorObject      `json:"error"`
+}
+
+func NewAnthropicClient(cfg Config, httpClient *http.Client) *AnthropicClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultAnthropicURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultAnthropicModel
+	}
+	maxTokens := cfg.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+	return &AnthropicClient{
+		apiKey:     cfg.APIKey,
+		apiURL:     strings.TrimSuffix(baseURL, "/") + "/v1/messages",
+		model:      model,
+		maxTokens:  maxTokens,
+		retry:      cfg.Retry.withDefaults(),
+		httpClient: httpClient,
+	}
+}
+
+func (c *AnthropicClient) Name() string {
+	return ProviderAnthropic
+}
+
+func (c *AnthropicClient) Model() string {
+	return c.model
+}
+
+// newRequest moves system messages to the dedicated field, the Messages API accepts only user and assistant roles.
+func (c *AnthropicClient) newRequest(messages []Message) anthropicRequest {
+	req := anthropicRequest{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+	}
+	var system []string
+	for _, message := range messages {
+		if message.Role == RoleSystem {
+			system = append(system, message.Content)
+			continue
+		}
+		req.Messages = append(req.Messages, message)
+	}
+	req.System = strings.Join(system, "\n\n")
+	return req
+}
+
+func (c *AnthropicClient) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+func (c *AnthropicClient) SendMessage(ctx context.Context, messages []Message) (string, error) {
+	body, err := postJSON(ctx, c.httpClient, c.retry, c.apiURL, c.headers(), c.newRequest(messages))
+	if err != nil {
+		return "", err
+	}
+	var r anthropicResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", err
+	}
+	if r.StopReason == anthropicRefusal {
+		return "", ErrContentFiltered
+	}
+
+	var res strings.Builder
+	for _, content := range r.Content {
+		if content.Type == "text" {
+			res.WriteString(content.Text)
+		}
+	}
+	if res.Len() == 0 {
+		return "", errors.New("No text content found in json")
+	}
+	return res.String(), nil
+}
+
+func (c *AnthropicClient) StreamMessage(ctx context.Context, messages []Message, onDelta func(delta string)) (string, error) {
+	reqBody := c.newRequest(messages)
+	reqBody.Stream = true
+
+	var res strings.Builder
+	err := postStream(ctx, c.httpClient, c.retry, c.apiURL, c.headers(), reqBody, func(event, data string) error {
+		var e anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return err
+		}
+		switch e.Type {
+		case "content_block_delta":
+			if e.Delta.Type != "text_delta" || e.Delta.Text == "" {
+				return nil
+			}
+			res.WriteString(e.Delta.Text)
+			onDelta(e.Delta.Text)
+		case "message_delta":
+			if e.Delta.StopReason == anthropicRefusal {
+				return ErrContentFiltered
+			}
+		case "message_stop":
+			return errStopStream
+		case "error":
+			return newAPIError(0, nil, e.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return res.String(), err
+	}
+	if res.Len() == 0 {
+		return "", errors.New("Empty stream")
+	}
+	return res.String(), nil
+}
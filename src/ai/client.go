@@ -2,25 +2,15 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 
 	"golang.org/x/net/proxy"
 )
 
-type Client struct {
-	apiKey     string
-	apiURL     string
-	proxyURL   string
-	httpClient *http.Client
-}
-
-var model string = "gpt-4.1"
-
 type MessageRole string
 
 const (
@@ -34,116 +24,147 @@ type Message struct {
 	Content string      `json:"content"`
 }
 
-type Request struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+type ErrorResponse struct {
+	Error ErrorObject `json:"error"`
 }
-
-type Response struct {
-	Choices []Choice `json:"choices"`
+type ErrorObject struct {
+	Message string    `json:"message"`
+	Type    string    `json:"type"`
+	Code    ErrorCode `json:"code"`
 }
-type Choice struct {
-	Message Message `json:"message"`
+
+// ErrorCode is the code of the error, a string for OpenAI and a number for some
+// compatible servers such as llama.cpp and vLLM.
+type ErrorCode string
+
+func (c *ErrorCode) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*c = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var code string
+		if err := json.Unmarshal(data, &code); err != nil {
+			return err
+		}
+		*c = ErrorCode(code)
+		return nil
+	}
+	var code json.Number
+	if err := json.Unmarshal(data, &code); err != nil {
+		return fmt.Errorf("error code %s is neither a string nor a number", data)
+	}
+	*c = ErrorCode(code)
+	return nil
 }
 
-type ErrorResponse struct {
-	Error ErrorObject `json:"error"`
+// Provider is a language model backend able to answer a chat conversation.
+type Provider interface {
+	// Name returns the provider identifier, e.g. "openai".
+	Name() string
+	// Model returns the model used for completions.
+	Model() string
+	SendMessage(ctx context.Context, messages []Message) (string, error)
+	// StreamMessage calls onDelta with every piece of the answer as soon as it arrives
+	// and returns the whole answer when the stream is finished.
+	StreamMessage(ctx context.Context, messages []Message, onDelta func(delta string)) (string, error)
 }
-type ErrorObject struct {
-	Message string `json:"message"`
-	Type    string `json:"type"`
+
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderFake      = "fake"
+)
+
+type Config struct {
+	Provider string
+	APIKey   string
+	// BaseURL overrides the default API endpoint, e.g. http://localhost:11434/v1 for Ollama.
+	BaseURL string
+	Model   string
+	// ProxyURL is an optional SOCKS5 proxy address.
+	ProxyURL  string
+	MaxTokens int
+	// Retry sets up the retries of the failed requests, the defaults are used for the zero fields.
+	Retry RetryPolicy
 }
 
-var GPTClient *Client
+func NewProvider(cfg Config) (Provider, error) {
+	if cfg.Provider == ProviderFake {
+		return NewFakeProvider(), nil
+	}
+	httpClient, err := newHTTPClient(cfg.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Provider {
+	case ProviderOpenAI, "":
+		return NewOpenAIClient(cfg, httpClient), nil
+	case ProviderAnthropic:
+		return NewAnthropicClient(cfg, httpClient), nil
+	}
+	return nil, fmt.Errorf("unknown ai provider %q", cfg.Provider)
+}
 
-func Init(apiKey, proxyURL string) error {
+func newHTTPClient(proxyURL string) (*http.Client, error) {
+	if proxyURL == "" {
+		return &http.Client{}, nil
+	}
 	dialer, err := proxy.SOCKS5("tcp", proxyURL, nil, proxy.Direct)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	transport := &http.Transport{
 		Dial: dialer.Dial,
 	}
-	httpClient := &http.Client{
+	return &http.Client{
 		Transport: transport,
-	}
-
-	GPTClient = &Client{
-		apiKey:     apiKey,
-		apiURL:     "https://api.openai.com/v1/chat/completions",
-		proxyURL:   proxyURL,
-		httpClient: httpClient,
-	}
-
-	return nil
+	}, nil
 }
 
-func (c *Client) SendMessage(messages []Message) (string, error) {
-	reqBody := Request{
-		Model:    model,
-		Messages: messages,
-	}
-
+// postJSON sends the request body to url and returns the body of a successful response.
+// Temporary failures are retried with the policy, the errors of the API are *APIError.
+func postJSON(ctx context.Context, httpClient *http.Client, policy RetryPolicy, url string, headers map[string]string, reqBody any) ([]byte, error) {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var lastErrBody string
-
-	for range 5 {
-		req, err := http.NewRequest("POST", c.apiURL, bytes.NewBuffer(jsonData))
+	var body []byte
+	err = policy.do(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+		req, err := newPostRequest(ctx, url, headers, jsonData)
 		if err != nil {
-			return "", err
+			return err
 		}
-
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-		resp, err := c.httpClient.Do(req)
+		resp, err := httpClient.Do(req)
 		if err != nil {
-			return "", err
+			return err
 		}
 		defer resp.Body.Close()
 
-		var errResp ErrorResponse
-		if resp.StatusCode != http.StatusOK {
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				lastErrBody = "unable to parse error body"
-				time.Sleep(1)
-				continue
-			}
-			if err := json.Unmarshal([]byte(body), &errResp); err == nil && errResp.Error.Message != "" {
-				lastErrBody = fmt.Sprintf("API error, type: %s: %s", errResp.Error.Type, errResp.Error.Message)
-				continue
-			}
-		}
-
-		if resp.StatusCode == http.StatusTooManyRequests {
-			time.Sleep(1)
-			continue
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("API error status %d, type: %s: %s", resp.StatusCode, errResp.Error.Type, errResp.Error.Message)
-		}
-
-		body, err := io.ReadAll(resp.Body)
+		data, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return "", err
+			return err
 		}
-		var r Response
-		if err := json.Unmarshal([]byte(body), &r); err != nil {
-			return "", err
+		if resp.StatusCode != http.StatusOK {
+			return parseAPIError(resp.StatusCode, resp.Header, data)
 		}
+		body = data
+		return nil
+	})
+	return body, err
+}
 
-		if len(r.Choices) > 0 {
-			content := r.Choices[0].Message.Content
-			return content, nil
-		}
-		return "", errors.New("No choices found in json")
+func newPostRequest(ctx context.Context, url string, headers map[string]string, jsonData []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
 	}
-
-	return "", errors.New("Retries exceeded: " + lastErrBody)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
 }
@@ -0,0 +1,252 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// errAny is expected when the test accepts any error.
+var errAny = errors.New("any error")
+
+var conversation = []Message{
+	{Role: RoleSystem, Content: "Ты нумеролог"},
+	{Role: RoleUser, Content: "Число 7"},
+}
+
+// testServer answers every request with the status and the body, the request is passed to check.
+func testServer(t *testing.T, status int, body string, check func(r *http.Request, body map[string]any)) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var req map[string]any
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("request is not JSON: %s", data)
+		}
+		if check != nil {
+			check(r, req)
+		}
+		if strings.HasPrefix(body, "data:") || strings.HasPrefix(body, "event:") {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func newTestProvider(t *testing.T, provider, url string) Provider {
+	t.Helper()
+	p, err := NewProvider(Config{Provider: provider, APIKey: "key", BaseURL: url, Model: "model", Retry: RetryPolicy{MaxAttempts: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSendMessage(t *testing.T) {
+	tests := []struct {
+		name, provider string
+		status         int
+		body           string
+		want           string
+		err            error
+	}{
+		{"openai", ProviderOpenAI, 200, `{"choices":[{"message":{"role":"assistant","content":"Ответ"},"finish_reason":"stop"}]}`, "Ответ", nil},
+		{"openai filtered", ProviderOpenAI, 200, `{"choices":[{"message":{"content":""},"finish_reason":"content_filter"}]}`, "", ErrContentFiltered},
+		{"openai no choices", ProviderOpenAI, 200, `{"choices":[]}`, "", errAny},
+		{"openai error", ProviderOpenAI, 401, `{"error":{"message":"Incorrect API key","code":"invalid_api_key"}}`, "", ErrAuth},
+		{"anthropic", ProviderAnthropic, 200, `{"content":[{"type":"text","text":"От"},{"type":"text","text":"вет"}],"stop_reason":"end_turn"}`, "Ответ", nil},
+		{"anthropic refusal", ProviderAnthropic, 200, `{"content":[],"stop_reason":"refusal"}`, "", ErrContentFiltered},
+		{"anthropic no text", ProviderAnthropic, 200, `{"content":[{"type":"tool_use"}]}`, "", errAny},
+		{"anthropic error", ProviderAnthropic, 400, `{"error":{"type":"invalid_request_error","message":"prompt is too long"}}`, "", ErrContextTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := testServer(t, tt.status, tt.body, nil)
+			got, err := newTestProvider(t, tt.provider, url).SendMessage(context.Background(), conversation)
+			switch {
+			case tt.err == nil && err != nil:
+				t.Fatalf("error = %v", err)
+			case tt.err != nil && err == nil:
+				t.Fatalf("no error, want %v", tt.err)
+			case tt.err != nil && tt.err != errAny && !errors.Is(err, tt.err):
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("answer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequests(t *testing.T) {
+	t.Run("openai", func(t *testing.T) {
+		url := testServer(t, 200, `{"choices":[{"message":{"content":"ok"}}]}`, func(r *http.Request, body map[string]any) {
+			if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+				t.Errorf("request to %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+			}
+			if body["model"] != "model" || len(body["messages"].([]any)) != 2 {
+				t.Errorf("request body %v", body)
+			}
+		})
+		newTestProvider(t, ProviderOpenAI, url+"/").SendMessage(context.Background(), conversation)
+	})
+	t.Run("anthropic", func(t *testing.T) {
+		url := testServer(t, 200, `{"content":[{"type":"text","text":"ok"}]}`, func(r *http.Request, body map[string]any) {
+			if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") == "" {
+				t.Errorf("request to %s with %v", r.URL.Path, r.Header)
+			}
+			// The system message is moved to its own field.
+			if body["system"] != "Ты нумеролог" || len(body["messages"].([]any)) != 1 || body["max_tokens"] == nil {
+				t.Errorf("request body %v", body)
+			}
+		})
+		newTestProvider(t, ProviderAnthropic, url).SendMessage(context.Background(), conversation)
+	})
+}
+
+func TestStreamMessage(t *testing.T) {
+	tests := []struct {
+		name, provider string
+		body           string
+		want           string
+		err            error
+	}{
+		{"openai", ProviderOpenAI, "data: {\"choices\":[{\"delta\":{\"content\":\"От\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"вет\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+			"data: [DONE]\n\n", "Ответ", nil},
+		{"openai filtered", ProviderOpenAI, "data: {\"choices\":[{\"delta\":{\"content\":\"От\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"content_filter\"}]}\n\n", "От", ErrContentFiltered},
+		{"openai error", ProviderOpenAI, "data: {\"error\":{\"type\":\"server_error\",\"message\":\"failed\"}}\n\n", "", &APIError{}},
+		{"openai empty", ProviderOpenAI, "data: [DONE]\n\n", "", errAny},
+		{"anthropic", ProviderAnthropic, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n" +
+			": ping\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"От\"}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"вет\"}}\n\n" +
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n", "Ответ", nil},
+		{"anthropic refusal", ProviderAnthropic, "event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"refusal\"}}\n\n", "", ErrContentFiltered},
+		{"anthropic error", ProviderAnthropic, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n", "", &APIError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := testServer(t, 200, tt.body, func(r *http.Request, body map[string]any) {
+				if body["stream"] != true {
+					t.Errorf("stream is not requested: %v", body)
+				}
+			})
+			var deltas strings.Builder
+			got, err := newTestProvider(t, tt.provider, url).StreamMessage(context.Background(), conversation, func(delta string) {
+				deltas.WriteString(delta)
+			})
+			var apiErr *APIError
+			switch {
+			case tt.err == nil && err != nil:
+				t.Fatalf("error = %v", err)
+			case tt.err != nil && err == nil:
+				t.Fatalf("no error, want %v", tt.err)
+			case errors.As(tt.err, &apiErr) && !errors.As(err, &apiErr):
+				t.Fatalf("error = %v, want an API error", err)
+			case tt.err == ErrContentFiltered && !errors.Is(err, ErrContentFiltered):
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want || deltas.String() != tt.want {
+				t.Errorf("answer = %q, deltas = %q, want %q", got, deltas.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	type event struct{ event, data string }
+	tests := []struct {
+		name   string
+		stream string
+		want   []event
+	}{
+		{"data only", "data: a\n\ndata: b\n\n", []event{{"", "a"}, {"", "b"}}},
+		{"named", "event: e\ndata: a\n\n", []event{{"e", "a"}}},
+		{"multiline data", "data: a\ndata: b\n\n", []event{{"", "a\nb"}}},
+		{"comments and empty events", ": ping\n\nevent: e\n\ndata: a\n\n", []event{{"", "a"}}},
+		{"no space after colon", "data:a\n\n", []event{{"", "a"}}},
+		{"last event without blank line", "data: a", []event{{"", "a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []event
+			err := readSSE(strings.NewReader(tt.stream), func(e, data string) error {
+				got = append(got, event{e, data})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("event %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	stops := 0
+	err := readSSE(strings.NewReader("data: a\n\ndata: b\n\n"), func(e, data string) error {
+		stops++
+		return errStopStream
+	})
+	if !errors.Is(err, errStopStream) || stops != 1 {
+		t.Errorf("the stream is read after the stop: %v, %d events", err, stops)
+	}
+}
+
+func TestFakeProvider(t *testing.T) {
+	p := NewFakeProvider()
+	first, err := p.SendMessage(context.Background(), conversation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := p.SendMessage(context.Background(), conversation)
+	if first != second || !strings.HasSuffix(first, "Число 7") {
+		t.Errorf("answers %q and %q", first, second)
+	}
+	var deltas strings.Builder
+	streamed, err := p.StreamMessage(context.Background(), conversation, func(delta string) { deltas.WriteString(delta) })
+	if err != nil || streamed != first || deltas.String() != first {
+		t.Errorf("stream = %q, deltas = %q, %v", streamed, deltas.String(), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.SendMessage(ctx, conversation); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled request: %v", err)
+	}
+}
+
+func TestStreamMessageStalled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"От\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		// The stream stalls after the first event until the client goes away.
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := newTestProvider(t, ProviderOpenAI, srv.URL).StreamMessage(ctx, conversation, func(string) {})
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("stalled stream returned %v after %s", err, time.Since(start))
+	}
+}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of the API errors, APIError unwraps to one of them. None of them is retried.
+var (
+	ErrAuth            = errors.New("ai: authentication failed")
+	ErrQuotaExhausted  = errors.New("ai: quota exhausted")
+	ErrContentFiltered = errors.New("ai: content filtered")
+	ErrContextTooLong  = errors.New("ai: context too long")
+)
+
+// APIError is an error answered by the API, either with a status code or as an event of a stream.
+type APIError struct {
+	// StatusCode is 0 for the errors sent in a stream.
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+	// RetryAfter is the delay asked by the Retry-After header.
+	RetryAfter time.Duration
+	kind       error
+}
+
+func newAPIError(statusCode int, header http.Header, obj ErrorObject) *APIError {
+	e := &APIError{StatusCode: statusCode, Type: obj.Type, Code: string(obj.Code), Message: obj.Message}
+	if header != nil {
+		e.RetryAfter = parseRetryAfter(header.Get("Retry-After"))
+	}
+	e.kind = classify(e)
+	return e
+}
+
+// parseAPIError makes the error of a response with the body, which is used as the message when it is not JSON.
+func parseAPIError(statusCode int, header http.Header, body []byte) *APIError {
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error.Message == "" {
+		errResp.Error.Message = strings.TrimSpace(string(body[:min(len(body), 200)]))
+	}
+	return newAPIError(statusCode, header, errResp.Error)
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("API error, type: %s: %s", e.Type, e.Message)
+	}
+	return fmt.Sprintf("API error status %d, type: %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// Temporary reports whether the request may succeed when it is sent again:
+// rate limits, overloads and server errors.
+func (e *APIError) Temporary() bool {
+	if e.kind != nil {
+		return false
+	}
+	switch e.Type {
+	case "rate_limit_error", "overloaded_error", "api_error", "server_error":
+		return true
+	}
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// classify finds the kind of the error from the fields used by OpenAI and Anthropic.
+func classify(e *APIError) error {
+	message := strings.ToLower(e.Message)
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden,
+		e.Type == "authentication_error", e.Type == "permission_error", e.Code == "invalid_api_key":
+		return ErrAuth
+	case e.StatusCode == http.StatusPaymentRequired, e.Code == "insufficient_quota", e.Type == "insufficient_quota",
+		e.Type == "billing_error", strings.Contains(message, "credit balance is too low"):
+		return ErrQuotaExhausted
+	case e.StatusCode == http.StatusRequestEntityTooLarge, e.Code == "context_length_exceeded", e.Type == "request_too_large",
+		e.Type == "exceed_context_size_error",
+		strings.Contains(message, "context length"), strings.Contains(message, "prompt is too long"):
+		return ErrContextTooLong
+	case e.Code == "content_filter", e.Code == "content_policy_violation", strings.Contains(message, "content management policy"):
+		return ErrContentFiltered
+	}
+	return nil
+}
+
+// parseRetryAfter reads the delay given in seconds or as a date, 0 when there is none.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return max(time.Duration(seconds*float64(time.Second)), 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAPIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		kind      error
+		temporary bool
+	}{
+		{"rate limit", 429, `{"error":{"type":"rate_limit_error","message":"slow down"}}`, nil, true},
+		{"overloaded", 529, `{"error":{"type":"overloaded_error","message":"Overloaded"}}`, nil, true},
+		{"server error", 500, `{"error":{"type":"server_error","message":"oops"}}`, nil, true},
+		{"bad gateway html", 502, `<html>Bad Gateway</html>`, nil, true},
+		{"request timeout", 408, ``, nil, true},
+		{"openai quota", 429, `{"error":{"type":"insufficient_quota","code":"insufficient_quota","message":"You exceeded your current quota"}}`, ErrQuotaExhausted, false},
+		{"anthropic credit", 400, `{"error":{"type":"invalid_request_error","message":"Your credit balance is too low"}}`, ErrQuotaExhausted, false},
+		{"unauthorized", 401, `{"error":{"type":"invalid_request_error","code":"invalid_api_key","message":"Incorrect API key"}}`, ErrAuth, false},
+		{"forbidden", 403, `{"error":{"type":"permission_error","message":"no access"}}`, ErrAuth, false},
+		{"context length", 400, `{"error":{"type":"invalid_request_error","code":"context_length_exceeded","message":"too long"}}`, ErrContextTooLong, false},
+		{"prompt too long", 400, `{"error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens"}}`, ErrContextTooLong, false},
+		{"content filter", 400, `{"error":{"code":"content_filter","message":"filtered"}}`, ErrContentFiltered, false},
+		{"numeric code", 400, `{"error":{"code":400,"message":"the request exceeds the available context size","type":"exceed_context_size_error"}}`, ErrContextTooLong, false},
+		{"numeric code server error", 503, `{"error":{"code":503,"message":"Loading model","type":"unavailable_error"}}`, nil, true},
+		{"null code", 400, `{"error":{"code":null,"type":"invalid_request_error","message":"wrong model"}}`, nil, false},
+		{"bad request", 400, `{"error":{"type":"invalid_request_error","message":"wrong model"}}`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseAPIError(tt.status, nil, []byte(tt.body))
+			if tt.kind != nil && !errors.Is(err, tt.kind) {
+				t.Errorf("error %v is not %v", err, tt.kind)
+			}
+			if tt.kind == nil && err.kind != nil {
+				t.Errorf("error %v is classified as %v", err, err.kind)
+			}
+			if err.Temporary() != tt.temporary {
+				t.Errorf("Temporary() = %v, want %v", err.Temporary(), tt.temporary)
+			}
+			if err.Message == "" && tt.body != "" {
+				t.Error("the message is lost")
+			}
+			if strings.HasPrefix(tt.body, "{") && strings.HasPrefix(err.Message, "{") {
+				t.Errorf("the body is not decoded: %s", err.Message)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"0.5", 500 * time.Millisecond, 500 * time.Millisecond},
+		{"-1", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s..%s", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// FakeProvider answers without any network calls. The answer depends only on the
+// conversation, so it is suitable for tests and local runs.
+type FakeProvider struct{}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+func (p *FakeProvider) Model() string {
+	return "fake"
+}
+
+func (p *FakeProvider) SendMessage(ctx context.Context, messages []Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	h := fnv.New32a()
+	var last string
+	for _, message := range messages {
+		h.Write([]byte(message.Role))
+		h.Write([]byte(message.Content))
+		if message.Role == RoleUser {
+			last = message.Content
+		}
+	}
+	return fmt.Sprintf("Тестовый прогноз #%08x\n\n%s", h.Sum32(), last), nil
+}
+
+// StreamMessage emits the same answer as SendMessage word by word.
+func (p *FakeProvider) StreamMessage(ctx context.Context, messages []Message, onDelta func(delta string)) (string, error) {
+	answer, err := p.SendMessage(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	for _, word := range strings.SplitAfter(answer, " ") {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		onDelta(word)
+	}
+	return answer, nil
+}
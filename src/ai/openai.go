@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultOpenAIURL   = "https://api.openai.com/v1"
+	defaultOpenAIModel = "gpt-4.1"
+)
+
+// OpenAIClient talks to any OpenAI compatible chat completions endpoint:
+// OpenAI itself, llama.cpp server, Ollama, vLLM and so on.
+type OpenAIClient struct {
+	apiKey     string
+	apiURL     string
+	model      string
+	retry      RetryPolicy
+	httpClient *http.Client
+}
+
+type openAIRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []openAIChoice `json:"choices"`
+	// Error is sent instead of the choices when the stream fails.
+	Error *ErrorObject `json:"error"`
+}
+type openAIChoice struct {
+	Message      Message `json:"message"`
+	Delta        Message `json:"delta"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// openAIContentFilter is the finish reason of the answers stopped by the content filters.
+const openAIContentFilter = "content_filter"
+
+func NewOpenAIClient(cfg Config, httpClient *http.Client) *OpenAIClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	return &OpenAIClient{
+		apiKey:     cfg.APIKey,
+		apiURL:     strings.TrimSuffix(baseURL, "/") + "/chat/completions",
+		model:      model,
+		retry:      cfg.Retry.withDefaults(),
+		httpClient: httpClient,
+	}
+}
+
+func (c *OpenAIClient) Name() string {
+	return ProviderOpenAI
+}
+
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+func (c *OpenAIClient) headers() map[string]string {
+	headers := map[string]string{}
+	// Local servers usually run without authorization.
+	if c.apiKey != "" {
+		headers["Authorization"] = "Bearer " + c.apiKey
+	}
+	return headers
+}
+
+func (c *OpenAIClient) SendMessage(ctx context.Context, messages []Message) (string, error) {
+	reqBody := openAIRequest{
+		Model:    c.model,
+		Messages: messages,
+	}
+
+	body, err := postJSON(ctx, c.httpClient, c.retry, c.apiURL, c.headers(), reqBody)
+	if err != nil {
+		return "", err
+	}
+	var r openAIResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", err
+	}
+
+	if len(r.Choices) > 0 {
+		if r.Choices[0].FinishReason == openAIContentFilter {
+			return "", ErrContentFiltered
+		}
+		content := r.Choices[0].Message.Content
+		return content, nil
+	}
+	return "", errors.New("No choices found in json")
+}
+
+func (c *OpenAIClient) StreamMessage(ctx context.Context, messages []Message, onDelta func(delta string)) (string, error) {
+	reqBody := openAIRequest{
+		Model:    c.model,
+		Messages: messages,
+		Stream:   true,
+	}
+
+	var res strings.Builder
+	err := postStream(ctx, c.httpClient, c.retry, c.apiURL, c.headers(), reqBody, func(event, data string) error {
+		if data == "[DONE]" {
+			return errStopStream
+		}
+		var chunk openAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != nil {
+			return newAPIError(0, nil, *chunk.Error)
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		if chunk.Choices[0].FinishReason == openAIContentFilter {
+			return ErrContentFiltered
+		}
+		if chunk.Choices[0].Delta.Content == "" {
+			return nil
+		}
+		delta := chunk.Choices[0].Delta.Content
+		res.WriteString(delta)
+		onDelta(delta)
+		return nil
+	})
+	if err != nil {
+		return res.String(), err
+	}
+	if res.Len() == 0 {
+		return "", errors.New("Empty stream")
+	}
+	return res.String(), nil
+}
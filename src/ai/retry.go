@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"tgbot-numerologist/utils"
+)
+
+// RetryPolicy sets up the retries of the requests failed with rate limits, server errors and timeouts.
+type RetryPolicy struct {
+	// MaxAttempts is the number of the requests made, 1 turns the retries off.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it is doubled for every next one up to MaxDelay.
+	// The delays are randomized by half, Retry-After of the response is used as is,
+	// a request is not retried when Retry-After is longer than MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Timeout limits every request, for a stream it limits the wait for the response only.
+	Timeout time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 4
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = time.Second
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 30 * time.Second
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = 2 * time.Minute
+	}
+	return p
+}
+
+// do calls try until it succeeds, fails with an error which is not retried or the attempts are over.
+func (p RetryPolicy) do(ctx context.Context, try func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := try(ctx)
+		if err == nil || attempt >= p.MaxAttempts || !retryable(ctx, err) {
+			return err
+		}
+		delay := p.delay(attempt, err)
+		if delay > p.MaxDelay {
+			utils.Log("ai request failed, attempt %d, not retried, Retry-After %s is longer than %s: %v", attempt, delay, p.MaxDelay, err)
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+		utils.Log("ai request failed, attempt %d, retry in %s: %v", attempt, delay.Round(time.Millisecond), err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	delay := p.MaxDelay
+	if attempt < 32 {
+		delay = min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryable reports whether the request failed with a temporary error and the caller still waits for it.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
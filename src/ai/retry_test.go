@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"tgbot-numerologist/utils"
+)
+
+func TestMain(m *testing.M) {
+	utils.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// timeoutError is a network timeout, as returned by the HTTP client.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func apiError(status int, body string) error {
+	return parseAPIError(status, nil, []byte(body))
+}
+
+func retryAfter(seconds string) error {
+	return newAPIError(429, http.Header{"Retry-After": {seconds}}, ErrorObject{Type: "rate_limit_error"})
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	overloaded := apiError(529, `{"error":{"type":"overloaded_error","message":"Overloaded"}}`)
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{"success", []error{nil}, 1, nil},
+		{"rate limit then success", []error{apiError(429, ""), nil}, 2, nil},
+		{"server errors then success", []error{overloaded, apiError(502, "Bad Gateway"), nil}, 3, nil},
+		{"network timeout then success", []error{timeoutError{}, nil}, 2, nil},
+		{"deadline then success", []error{context.DeadlineExceeded, nil}, 2, nil},
+		{"attempts are over", []error{overloaded, overloaded, overloaded, overloaded, nil}, 3, overloaded},
+		{"quota is not retried", []error{apiError(429, `{"error":{"code":"insufficient_quota","message":"quota"}}`)}, 1, ErrQuotaExhausted},
+		{"auth is not retried", []error{apiError(401, "")}, 1, ErrAuth},
+		{"context length is not retried", []error{apiError(400, `{"error":{"code":"context_length_exceeded","message":"long"}}`)}, 1, ErrContextTooLong},
+		{"bad request is not retried", []error{apiError(400, "bad")}, 1, nil},
+		{"other errors are not retried", []error{errors.New("broken"), nil}, 1, nil},
+		{"short Retry-After is retried", []error{retryAfter("0.001"), nil}, 2, nil},
+		{"long Retry-After is not retried", []error{retryAfter("60"), nil}, 1, nil},
+	}
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}.withDefaults()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := policy.do(context.Background(), func(ctx context.Context) error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+			if attempts != tt.attempts {
+				t.Errorf("made %d attempts, want %d", attempts, tt.attempts)
+			}
+			last := tt.errs[attempts-1]
+			if !errors.Is(err, last) || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoStopsWithContext(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	attempts := 0
+	start := time.Now()
+	err := policy.do(ctx, func(ctx context.Context) error {
+		attempts++
+		return apiError(503, "unavailable")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("got %v after %d attempts", err, attempts)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("waited %s for a retry past the deadline", time.Since(start))
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}.withDefaults()
+	overloaded := apiError(529, "")
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{5, 5 * time.Second, 10 * time.Second},
+		{40, 5 * time.Second, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if got := policy.delay(tt.attempt, overloaded); got < tt.min || got > tt.max {
+				t.Errorf("delay(%d) = %s, want %s..%s", tt.attempt, got, tt.min, tt.max)
+				break
+			}
+		}
+	}
+
+	if got := policy.delay(1, retryAfter("3")); got != 3*time.Second {
+		t.Errorf("delay with Retry-After 3 = %s", got)
+	}
+}
@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// readSSE parses a server-sent events stream and calls handle for every dispatched event.
+// Returning errStopStream from handle finishes reading without error.
+func readSSE(r io.Reader, handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data []string
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := handle(event, strings.Join(data, "\n"))
+		event = ""
+		data = data[:0]
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := dispatch(); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
+
+var errStopStream = errors.New("stop stream")
+
+// postStream sends the request body to url and calls handle for every event of the response stream.
+// The request is retried with the policy until the response starts, the stream itself is never repeated.
+func postStream(ctx context.Context, httpClient *http.Client, policy RetryPolicy, url string, headers map[string]string, reqBody any, handle func(event, data string) error) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	var resp *http.Response
+	var cancelStream context.CancelFunc
+	err = policy.do(ctx, func(ctx context.Context) error {
+		req, err := newPostRequest(ctx, url, headers, jsonData)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		// Timeout limits the wait for the response, the stream may take longer.
+		requestCtx, cancel := context.WithCancel(ctx)
+		timer := time.AfterFunc(policy.Timeout, cancel)
+		r, err := httpClient.Do(req.WithContext(requestCtx))
+		timedOut := !timer.Stop()
+		if err != nil {
+			cancel()
+			if timedOut {
+				return fmt.Errorf("%w: no response in %s", context.DeadlineExceeded, policy.Timeout)
+			}
+			return err
+		}
+		if r.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			cancel()
+			return parseAPIError(r.StatusCode, r.Header, body)
+		}
+		resp, cancelStream = r, cancel
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	defer cancelStream()
+	defer resp.Body.Close()
+
+	err = readSSE(resp.Body, handle)
+	if err == errStopStream {
+		return nil
+	}
+	return err
+}
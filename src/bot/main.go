@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
+	"math"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"tgbot-numerologist/ai"
 	"tgbot-numerologist/communicate"
 	"tgbot-numerologist/database"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/scheduler"
 	"tgbot-numerologist/utils"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -26,28 +36,122 @@ func main() {
 	if token == "" {
 		log.Fatal("TELEGRAM_BOT_TOKEN environment variable is not set")
 	}
-	redisHost := os.Getenv("REDIS_HOST")
-	if redisHost == "" {
-		log.Fatal("REDIS_HOST environment variable is not set")
+	storeConfig := database.Config{
+		Backend:    getEnv("STORAGE", "redis"),
+		SQLitePath: getEnv("SQLITE_PATH", "/data/bot.db"),
 	}
-	redisPort := os.Getenv("REDIS_PORT")
-	if redisPort == "" {
-		log.Fatal("REDIS_PORT environment variable is not set")
+	if storeConfig.Backend == "redis" {
+		redisHost := os.Getenv("REDIS_HOST")
+		if redisHost == "" {
+			log.Fatal("REDIS_HOST environment variable is not set")
+		}
+		redisPort := os.Getenv("REDIS_PORT")
+		if redisPort == "" {
+			log.Fatal("REDIS_PORT environment variable is not set")
+		}
+		storeConfig.RedisAddr = redisHost + ":" + redisPort
 	}
-	proxyURL := os.Getenv("PROXY_URL")
-	if proxyURL == "" {
-		log.Fatal("PROXY_URL environment variable is not set")
+	aiConfig := ai.Config{
+		Provider: getEnv("AI_PROVIDER", ai.ProviderOpenAI),
+		APIKey:   getEnv("AI_API_KEY", os.Getenv("CHATGPT_KEY")),
+		BaseURL:  os.Getenv("AI_BASE_URL"),
+		Model:    os.Getenv("AI_MODEL"),
+		ProxyURL: os.Getenv("PROXY_URL"),
 	}
-	gptKey := os.Getenv("CHATGPT_KEY")
-	if gptKey == "" {
-		log.Fatal("CHATGPT_KEY environment variable is not set")
+	if aiConfig.APIKey == "" && aiConfig.Provider != ai.ProviderFake && aiConfig.BaseURL == "" {
+		log.Fatal("AI_API_KEY environment variable is not set")
+	}
+	aiRetries, err := strconv.Atoi(getEnv("AI_MAX_RETRIES", "3"))
+	if err != nil || aiRetries < 0 {
+		log.Fatalf("AI_MAX_RETRIES must be a non negative number")
+	}
+	aiConfig.Retry.MaxAttempts = aiRetries + 1
+	aiConfig.Retry.Timeout, err = time.ParseDuration(getEnv("AI_TIMEOUT", "2m"))
+	if err != nil || aiConfig.Retry.Timeout <= 0 {
+		log.Fatalf("AI_TIMEOUT must be a positive duration, e.g. 90s")
 	}
 
-	database.InitRDB(redisHost, redisPort)
-	err = ai.Init(gptKey, proxyURL)
+	store, err := database.Open(storeConfig)
+	if err != nil {
+		log.Fatalf("Failed to open %s storage: %v", storeConfig.Backend, err)
+	}
+	provider, err := ai.NewProvider(aiConfig)
+	if err != nil {
+		log.Fatalf("Couldn't init ai provider: %v", err)
+	}
+	utils.Log("Using ai provider %s with model %s", provider.Name(), provider.Model())
+	packages, err := objects.ParsePackages(getEnv("PAYMENT_PACKAGES", "small:1:50,medium:5:200,large:10:350"))
 	if err != nil {
-		log.Fatal("Couldn't init ai client: %w", err)
+		log.Fatalf("Wrong PAYMENT_PACKAGES: %v", err)
+	}
+	followUpCost, err := strconv.ParseFloat(getEnv("FOLLOWUP_COST", "0.25"), 64)
+	if err != nil || followUpCost < 0 {
+		log.Fatalf("FOLLOWUP_COST must be a non negative number")
+	}
+	tokenBudget, err := strconv.Atoi(getEnv("CONVERSATION_TOKEN_BUDGET", "4000"))
+	if err != nil || tokenBudget <= 0 {
+		log.Fatalf("CONVERSATION_TOKEN_BUDGET must be a positive number")
+	}
+	conversationTTL, err := time.ParseDuration(getEnv("CONVERSATION_TTL", "24h"))
+	if err != nil || conversationTTL <= 0 {
+		log.Fatalf("CONVERSATION_TTL must be a positive duration")
+	}
+	broadcastRate, err := strconv.ParseFloat(getEnv("BROADCAST_RATE", "20"), 64)
+	if err != nil || broadcastRate <= 0 {
+		log.Fatalf("BROADCAST_RATE must be a positive number")
+	}
+	sendRate, err := strconv.ParseFloat(getEnv("SEND_RATE", "30"), 64)
+	if err != nil || sendRate <= 0 {
+		log.Fatalf("SEND_RATE must be a positive number")
 	}
+	chatRate, err := strconv.ParseFloat(getEnv("SEND_CHAT_RATE", "1"), 64)
+	if err != nil || chatRate <= 0 {
+		log.Fatalf("SEND_CHAT_RATE must be a positive number")
+	}
+	chatBurst, err := strconv.Atoi(getEnv("SEND_CHAT_BURST", "3"))
+	if err != nil || chatBurst <= 0 {
+		log.Fatalf("SEND_CHAT_BURST must be a positive number")
+	}
+	sendRetries, err := strconv.Atoi(getEnv("SEND_MAX_RETRIES", "3"))
+	if err != nil || sendRetries < 0 {
+		log.Fatalf("SEND_MAX_RETRIES must be a non negative number")
+	}
+	forecastWorkers, err := strconv.Atoi(getEnv("FORECAST_WORKERS", "4"))
+	if err != nil || forecastWorkers <= 0 {
+		log.Fatalf("FORECAST_WORKERS must be a positive number")
+	}
+	var admins []int64
+	for _, id := range strings.Split(os.Getenv("ADMIN_IDS"), ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		adminID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			log.Fatalf("ADMIN_IDS must be comma separated Telegram user ids, got %q", id)
+		}
+		admins = append(admins, adminID)
+	}
+	communicate.Init(communicate.Services{
+		Provider: provider,
+		Store:    store,
+		Packages: packages,
+		// The journal is on the data volume, so the payments survive the restart while the store is down.
+		PaymentJournal: database.NewPaymentJournal(getEnv("PAYMENT_JOURNAL", "/data/payments_journal.jsonl")),
+		FollowUp: communicate.FollowUpConfig{
+			Cost:        int64(math.Round(followUpCost * objects.QuotaUnit)),
+			TokenBudget: tokenBudget,
+			TTL:         conversationTTL,
+		},
+		Broadcast: communicate.BroadcastConfig{Rate: broadcastRate},
+		Send: communicate.SendConfig{
+			GlobalRate: sendRate,
+			ChatRate:   chatRate,
+			ChatBurst:  chatBurst,
+			MaxRetries: sendRetries,
+		},
+		Forecast: communicate.ForecastConfig{Workers: forecastWorkers},
+		Admins:   admins,
+	})
 
 	bot, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
@@ -59,5 +163,109 @@ func main() {
 
 	utils.Log("Authorized on account %s", bot.Self.UserName)
 
-	communicate.StartReceivingUpdates(bot)
+	workers, err := strconv.Atoi(getEnv("WORKERS", "8"))
+	if err != nil || workers <= 0 {
+		log.Fatalf("WORKERS must be a positive number")
+	}
+	queueSize, err := strconv.Atoi(getEnv("QUEUE_SIZE", "256"))
+	if err != nil || queueSize <= 0 {
+		log.Fatalf("QUEUE_SIZE must be a positive number")
+	}
+	dispatcher := communicate.NewDispatcher(workers, queueSize, func(update tgbotapi.Update) {
+		communicate.HandleUpdate(bot, update)
+	})
+
+	updatesMode := getEnv("UPDATES_MODE", "polling")
+	var webhookConfig communicate.WebhookConfig
+	switch updatesMode {
+	case "polling":
+	case "webhook":
+		webhookConfig = communicate.WebhookConfig{
+			URL:         os.Getenv("WEBHOOK_URL"),
+			ListenAddr:  getEnv("WEBHOOK_LISTEN", ":8080"),
+			Path:        getEnv("WEBHOOK_PATH", "/telegram"),
+			SecretToken: os.Getenv("WEBHOOK_SECRET"),
+		}
+		if webhookConfig.URL == "" {
+			log.Fatal("WEBHOOK_URL environment variable is not set")
+		}
+		if webhookConfig.SecretToken == "" {
+			log.Fatal("WEBHOOK_SECRET environment variable is not set")
+		}
+	default:
+		log.Fatalf("Unknown UPDATES_MODE %q, expected polling or webhook", updatesMode)
+	}
+
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "50s"))
+	if err != nil {
+		log.Fatalf("SHUTDOWN_TIMEOUT is not a duration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	forecasts := scheduler.New(time.Minute, func(ctx context.Context, now time.Time) {
+		communicate.SendDueForecasts(ctx, bot, now)
+	})
+	forecasts.Start(ctx)
+	pendingPayments := scheduler.New(time.Minute, func(ctx context.Context, now time.Time) {
+		communicate.CreditPendingPayments(ctx, bot)
+	})
+	pendingPayments.Start(ctx)
+	communicate.StartBroadcasts(ctx, bot)
+
+	// The whole shutdown fits into SHUTDOWN_TIMEOUT from the signal, so the process is not killed in the middle of a write.
+	shutdownCtx, cancel := shutdownContext(ctx, shutdownTimeout)
+	defer cancel()
+
+	switch updatesMode {
+	case "webhook":
+		err = communicate.StartWebhook(ctx, bot, webhookConfig, dispatcher)
+		if err != nil {
+			utils.Log("Webhook server failed: %v", err)
+		}
+	default:
+		communicate.StartReceivingUpdates(ctx, bot, dispatcher)
+	}
+
+	// Stops the background jobs also when the webhook server failed by itself.
+	stop()
+
+	utils.Log("Shutting down, waiting for in-flight updates and background jobs up to %s", shutdownTimeout)
+	if err := dispatcher.Stop(shutdownCtx); err != nil {
+		utils.Log("Not all updates were handled before shutdown: %v", err)
+	}
+	if err := forecasts.Wait(shutdownCtx); err != nil {
+		utils.Log("Forecasts were not finished before shutdown: %v", err)
+	}
+	if err := pendingPayments.Wait(shutdownCtx); err != nil {
+		utils.Log("Pending payments were not finished before shutdown: %v", err)
+	}
+	if err := communicate.WaitBroadcasts(shutdownCtx); err != nil {
+		utils.Log("Broadcasts did not save their progress before shutdown: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		utils.Log("Error closing storage: %v", err)
+	}
+	utils.Log("Shutdown finished")
+}
+
+// shutdownContext is done timeout after ctx is done.
+func shutdownContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	shutdownCtx, cancel := context.WithCancel(context.Background())
+	stop := context.AfterFunc(ctx, func() {
+		timer := time.AfterFunc(timeout, cancel)
+		context.AfterFunc(shutdownCtx, func() { timer.Stop() })
+	})
+	return shutdownCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
 }
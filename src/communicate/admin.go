@@ -0,0 +1,282 @@
+package communicate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/ratelimit"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	adminCommandPrefix = "admin_"
+	adminStatsPageSize = 100
+	adminAuditLimit    = 20
+	// adminCallbackPrefix starts the data of the buttons shown to the admins.
+	adminCallbackPrefix = "admin:"
+)
+
+func isAdmin(userID int64) bool {
+	return slices.Contains(services.Admins, userID)
+}
+
+// deniedAudit limits the audit records of the commands denied to a user, so they cannot flood the log.
+var deniedAudit = ratelimit.NewKeyed(1.0/3600, 3)
+
+// authorizeAdmin records the admin command or button to the audit log and reports whether
+// the user may run it. Commands are not run without the audit record. Denied commands are
+// recorded only a few times an hour for a user.
+func authorizeAdmin(user *tgbotapi.User, command, args string) bool {
+	allowed := isAdmin(user.ID)
+	record := objects.NewAuditRecord(user.ID, user.UserName, command, args, allowed)
+	utils.Log("admin command /%s %q by %d allowed: %t", record.Command, record.Args, record.AdminID, allowed)
+	if !allowed && !deniedAudit.Allow(user.ID) {
+		return false
+	}
+	if err := services.Store.AddAuditRecord(context.Background(), record); err != nil {
+		utils.Log("error adding audit record: %v", err)
+		return false
+	}
+	return allowed
+}
+
+// HandleAdminCommand authorizes the admin command and runs it.
+// Users who are not admins get the same answer as for an unknown command.
+func HandleAdminCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	if !authorizeAdmin(message.From, message.Command(), message.CommandArguments()) {
+		SendText(bot, message.Chat.ID, i18n.T(profile.Language, i18n.ErrUnknownCommand))
+		return
+	}
+
+	switch message.Command() {
+	case "admin_user":
+		HandleAdminUser(bot, message, profile)
+	case "admin_grant":
+		HandleAdminGrant(bot, message, profile)
+	case "admin_ban":
+		HandleAdminBan(bot, message, profile)
+	case "admin_stats":
+		HandleAdminStats(bot, message, profile)
+	case "admin_broadcast":
+		HandleAdminBroadcast(bot, message, profile)
+	case "admin_broadcasts":
+		HandleAdminBroadcasts(bot, message, profile)
+	case "admin_audit":
+		HandleAdminAudit(bot, message, profile)
+	case "admin_dead_letters":
+		HandleAdminDeadLetters(bot, message, profile)
+	default:
+		SendText(bot, message.Chat.ID, i18n.T(profile.Language, "admin.help"))
+	}
+}
+
+// HandleAdminCallback authorizes the buttons shown to the admins: admin:bc:<action>:<id>.
+func HandleAdminCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	if !authorizeAdmin(callbackQuery.From, "callback", callbackQuery.Data) {
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+		return
+	}
+	switch {
+	case strings.HasPrefix(callbackQuery.Data, broadcastCallbackPrefix):
+		HandleBroadcastCallback(bot, callbackQuery, profile)
+	default:
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+	}
+}
+
+// findUser returns the profile of the user given by the id or the @username.
+func findUser(ctx context.Context, user string) (*objects.Profile, error) {
+	userID, err := strconv.ParseInt(user, 10, 64)
+	if err != nil {
+		userID, err = services.Store.GetUserIDByUsername(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return services.Store.GetProfile(ctx, userID)
+}
+
+// adminTarget finds the user given in the first argument, reports the errors to the admin.
+func adminTarget(bot *tgbotapi.BotAPI, chatID int64, lang string, args []string) (*objects.Profile, bool) {
+	if len(args) == 0 {
+		SendText(bot, chatID, i18n.T(lang, "admin.help"))
+		return nil, false
+	}
+	target, err := findUser(context.Background(), args[0])
+	if errors.Is(err, database.ErrNotFound) {
+		SendText(bot, chatID, i18n.T(lang, "admin.user_not_found", args[0]))
+		return nil, false
+	}
+	if err != nil {
+		utils.Log("error finding user %s: %v", args[0], err)
+		SendError(bot, chatID, i18n.Error(lang, i18n.ErrGotSomeProblems))
+		return nil, false
+	}
+	return target, true
+}
+
+// HandleAdminUser shows the stored profile and the quota: /admin_user <id|@username>.
+func HandleAdminUser(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	chatID := message.Chat.ID
+	target, ok := adminTarget(bot, chatID, profile.Language, strings.Fields(message.CommandArguments()))
+	if !ok {
+		return
+	}
+	quota, err := services.Store.GetQuota(context.Background(), target.UserID)
+	if err != nil {
+		utils.Log("error get quota of %d: %v", target.UserID, err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	data, err := json.MarshalIndent(target, "", "  ")
+	if err != nil {
+		utils.Log("error encoding profile of %d: %v", target.UserID, err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	text := i18n.T(profile.Language, "admin.user", target.UserID, quota.Available, quota.Predictions) + "\n\n" + string(data)
+	SendText(bot, chatID, truncateText(text))
+}
+
+// HandleAdminGrant adds quota to the user: /admin_grant <id|@username> <n>.
+func HandleAdminGrant(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	chatID := message.Chat.ID
+	args := strings.Fields(message.CommandArguments())
+	if len(args) != 2 {
+		SendText(bot, chatID, i18n.T(profile.Language, "admin.help"))
+		return
+	}
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || n <= 0 {
+		SendText(bot, chatID, i18n.T(profile.Language, "admin.wrong_number", args[1]))
+		return
+	}
+	target, ok := adminTarget(bot, chatID, profile.Language, args)
+	if !ok {
+		return
+	}
+	available, err := services.Store.AddQuota(context.Background(), target.UserID, n)
+	if err != nil {
+		utils.Log("error granting quota to %d: %v", target.UserID, err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	utils.Log("admin %d granted %d quota to %d, available %d", message.From.ID, n, target.UserID, available)
+	SendText(bot, chatID, i18n.T(profile.Language, "admin.granted", n, target.UserID, available))
+	SendText(bot, target.ChatID, i18n.T(target.Language, "admin.granted_user", n))
+}
+
+// HandleAdminBan blocks the user: /admin_ban <id|@username>, /admin_ban <id|@username> off unblocks.
+func HandleAdminBan(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	chatID := message.Chat.ID
+	args := strings.Fields(message.CommandArguments())
+	target, ok := adminTarget(bot, chatID, profile.Language, args)
+	if !ok {
+		return
+	}
+	banned := len(args) < 2 || args[1] != "off"
+	if banned && isAdmin(target.UserID) {
+		SendText(bot, chatID, i18n.T(profile.Language, "admin.ban_admin"))
+		return
+	}
+	_, err := services.Store.UpdateProfile(context.Background(), target.UserID, func(p *objects.Profile) error {
+		p.Banned = banned
+		return nil
+	})
+	if err != nil {
+		utils.Log("error banning %d: %v", target.UserID, err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	utils.Log("admin %d set banned of %d to %t", message.From.ID, target.UserID, banned)
+	if banned {
+		SendText(bot, chatID, i18n.T(profile.Language, "admin.banned", target.UserID))
+	} else {
+		SendText(bot, chatID, i18n.T(profile.Language, "admin.unbanned", target.UserID))
+	}
+}
+
+// HandleAdminStats counts the users going through all the profiles.
+func HandleAdminStats(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	ctx := context.Background()
+	var users, filled, subscribed, banned, predictions int64
+	var afterID int64
+	for {
+		profiles, err := services.Store.ListProfiles(ctx, afterID, adminStatsPageSize)
+		if err != nil {
+			utils.Log("error listing profiles for stats: %v", err)
+			SendError(bot, message.Chat.ID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+			return
+		}
+		for _, p := range profiles {
+			afterID = p.UserID
+			users++
+			if p.IsFilled() {
+				filled++
+			}
+			if p.Subscription != nil {
+				subscribed++
+			}
+			if p.Banned {
+				banned++
+			}
+			if quota, err := services.Store.GetQuota(ctx, p.UserID); err == nil {
+				predictions += quota.Predictions
+			}
+		}
+		if len(profiles) < adminStatsPageSize {
+			break
+		}
+	}
+	SendText(bot, message.Chat.ID, i18n.T(profile.Language, "admin.stats", users, filled, subscribed, banned, predictions))
+}
+
+// HandleAdminAudit shows the latest records of the audit log.
+func HandleAdminAudit(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	records, err := services.Store.ListAuditRecords(context.Background(), adminAuditLimit)
+	if err != nil {
+		utils.Log("error listing audit records: %v", err)
+		SendError(bot, message.Chat.ID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	var text strings.Builder
+	for _, record := range records {
+		mark := ""
+		if !record.Allowed {
+			mark = " ⛔"
+		}
+		fmt.Fprintf(&text, "%s %d @%s /%s %s%s\n", record.CreatedAt.Format("02.01.2006 15:04"),
+			record.AdminID, record.Username, record.Command, record.Args, mark)
+	}
+	SendText(bot, message.Chat.ID, truncateText(text.String()))
+}
+
+// HandleAdminDeadLetters shows the latest messages which could not be delivered.
+func HandleAdminDeadLetters(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	letters, err := services.Store.ListDeadLetters(context.Background(), adminAuditLimit)
+	if err != nil {
+		utils.Log("error listing dead letters: %v", err)
+		SendError(bot, message.Chat.ID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	if len(letters) == 0 {
+		SendText(bot, message.Chat.ID, i18n.T(profile.Language, "admin.no_dead_letters"))
+		return
+	}
+	var text strings.Builder
+	for _, letter := range letters {
+		fmt.Fprintf(&text, "%s chat %d %s, %d attempts: %s\n%s\n\n", letter.CreatedAt.Format("02.01.2006 15:04"),
+			letter.ChatID, letter.Method, letter.Attempts, letter.Error, previewText(letter.Text))
+	}
+	SendText(bot, message.Chat.ID, truncateText(text.String()))
+}
@@ -0,0 +1,370 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/ratelimit"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	broadcastCallbackPrefix = adminCallbackPrefix + "bc:"
+	broadcastPageSize       = 100
+	broadcastListLimit      = 5
+	// broadcastErrorDelay is the pause after the profiles could not be listed.
+	broadcastErrorDelay = 10 * time.Second
+)
+
+// BroadcastConfig sets up the broadcasts of the admins.
+type BroadcastConfig struct {
+	// Rate is the number of messages per second sent to all the chats, Telegram allows about 30.
+	Rate float64
+}
+
+// errBroadcastStopped is the cause of the cancellation of a broadcast stopped by the admin.
+var errBroadcastStopped = errors.New("broadcast stopped")
+
+// broadcastRunner sends the broadcasts in the background until the shutdown.
+type broadcastRunner struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	bot     *tgbotapi.BotAPI
+	wg      sync.WaitGroup
+	running map[string]context.CancelCauseFunc
+	// pace keeps the broadcasts slower than the limits of the send pipeline,
+	// so the answers to the users are not delayed.
+	pace *ratelimit.Limiter
+}
+
+var broadcasts = &broadcastRunner{running: make(map[string]context.CancelCauseFunc)}
+
+// StartBroadcasts resumes the broadcasts interrupted by the restart. The broadcasts
+// are run until ctx is done, the progress is kept, so they continue after the next start.
+func StartBroadcasts(ctx context.Context, bot *tgbotapi.BotAPI) {
+	r := broadcasts
+	r.mu.Lock()
+	r.ctx = ctx
+	r.bot = bot
+	r.pace = ratelimit.New(services.Broadcast.Rate, 1)
+	r.mu.Unlock()
+
+	list, err := services.Store.ListRunningBroadcasts(ctx)
+	if err != nil {
+		utils.Log("error listing broadcasts to resume: %v", err)
+		return
+	}
+	for _, broadcast := range list {
+		utils.Log("resume broadcast %s after user %d", broadcast.ID, broadcast.AfterID)
+		r.start(broadcast)
+	}
+}
+
+// WaitBroadcasts waits for the broadcasts to save their progress after the shutdown
+// or returns the error of ctx when it is done first.
+func WaitBroadcasts(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		broadcasts.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// start runs the broadcast in the background, false when it is already running or the bot is stopping.
+func (r *broadcastRunner) start(broadcast objects.Broadcast) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx == nil || r.ctx.Err() != nil {
+		return false
+	}
+	if _, ok := r.running[broadcast.ID]; ok {
+		return false
+	}
+	ctx, cancel := context.WithCancelCause(r.ctx)
+	r.running[broadcast.ID] = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			r.mu.Lock()
+			delete(r.running, broadcast.ID)
+			r.mu.Unlock()
+			cancel(nil)
+		}()
+		r.run(ctx, broadcast)
+	}()
+	return true
+}
+
+// stop cancels the running broadcast, false when it is not running.
+func (r *broadcastRunner) stop(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cancel, ok := r.running[id]
+	if ok {
+		cancel(errBroadcastStopped)
+	}
+	return ok
+}
+
+func (r *broadcastRunner) run(ctx context.Context, broadcast objects.Broadcast) {
+	for {
+		profiles, err := services.Store.ListProfiles(ctx, broadcast.AfterID, broadcastPageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				utils.Log("error listing profiles for broadcast %s: %v", broadcast.ID, err)
+				sleepContext(ctx, broadcastErrorDelay)
+				continue
+			}
+			break
+		}
+		for _, profile := range profiles {
+			if profile.Banned || profile.Inactive {
+				broadcast.Skipped++
+			} else {
+				err := r.send(ctx, &broadcast, profile.ChatID)
+				if ctx.Err() != nil {
+					// The message to this user is sent again after the restart.
+					break
+				}
+				switch {
+				case err == nil:
+					broadcast.Sent++
+				case isBotBlocked(err):
+					broadcast.Blocked++
+					markInactive(profile.UserID)
+				default:
+					utils.Log("error sending broadcast %s to %d: %v", broadcast.ID, profile.UserID, err)
+					broadcast.Failed++
+				}
+			}
+			broadcast.AfterID = profile.UserID
+			saveBroadcast(broadcast)
+		}
+		if ctx.Err() != nil || len(profiles) < broadcastPageSize {
+			break
+		}
+	}
+
+	if ctx.Err() != nil && !errors.Is(context.Cause(ctx), errBroadcastStopped) {
+		utils.Log("broadcast %s interrupted after user %d", broadcast.ID, broadcast.AfterID)
+		return
+	}
+	broadcast.Status = objects.BroadcastFinished
+	if ctx.Err() != nil {
+		broadcast.Status = objects.BroadcastCancelled
+	}
+	broadcast.FinishedAt = time.Now()
+	saveBroadcast(broadcast)
+	utils.Log("broadcast %s %s, sent %d, blocked %d, failed %d, skipped %d", broadcast.ID, broadcast.Status,
+		broadcast.Sent, broadcast.Blocked, broadcast.Failed, broadcast.Skipped)
+
+	lang := i18n.Default
+	if admin, err := services.Store.GetProfile(context.Background(), broadcast.AdminID); err == nil {
+		lang = admin.Language
+	}
+	SendText(r.bot, broadcast.AdminChatID, formatBroadcast(lang, broadcast))
+}
+
+// send delivers the broadcast to the chat through the send pipeline at the pace of the broadcasts.
+func (r *broadcastRunner) send(ctx context.Context, broadcast *objects.Broadcast, chatID int64) error {
+	if err := r.pace.Wait(ctx); err != nil {
+		return err
+	}
+	_, _, err := deliver(ctx, r.bot, chatID, broadcastMessage(broadcast, chatID))
+	return err
+}
+
+func broadcastMessage(broadcast *objects.Broadcast, chatID int64) tgbotapi.Chattable {
+	var markup any
+	if len(broadcast.Buttons) > 0 {
+		var keyboard [][]tgbotapi.InlineKeyboardButton
+		for _, button := range broadcast.Buttons {
+			keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(button.Text, button.URL)))
+		}
+		markup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	}
+	if broadcast.PhotoFileID != "" {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(broadcast.PhotoFileID))
+		photo.Caption = broadcast.Text
+		photo.ParseMode = broadcast.ParseMode
+		photo.ReplyMarkup = markup
+		return photo
+	}
+	msg := tgbotapi.NewMessage(chatID, broadcast.Text)
+	msg.ParseMode = broadcast.ParseMode
+	msg.ReplyMarkup = markup
+	return msg
+}
+
+// isBotBlocked reports whether the user blocked the bot or deleted the account.
+func isBotBlocked(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && (tgErr.Code == 403 || strings.HasPrefix(tgErr.Message, "Forbidden"))
+}
+
+func markInactive(userID int64) {
+	_, err := services.Store.UpdateProfile(context.Background(), userID, func(profile *objects.Profile) error {
+		profile.Inactive = true
+		return nil
+	})
+	if err != nil {
+		utils.Log("error marking user %d inactive: %v", userID, err)
+	}
+}
+
+// saveBroadcast keeps the progress, it is saved after the shutdown too.
+func saveBroadcast(broadcast objects.Broadcast) {
+	if err := services.Store.SaveBroadcast(context.Background(), broadcast); err != nil {
+		utils.Log("error saving broadcast %s: %v", broadcast.ID, err)
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func formatBroadcast(lang string, broadcast objects.Broadcast) string {
+	text := i18n.T(lang, "broadcast.status."+broadcast.Status, broadcast.ID, broadcast.CreatedAt.Format("02.01.2006 15:04"))
+	text += "\n" + i18n.T(lang, "broadcast.stats", broadcast.Sent, broadcast.Blocked, broadcast.Failed, broadcast.Skipped)
+	if !broadcast.FinishedAt.IsZero() && !broadcast.StartedAt.IsZero() {
+		text += "\n" + i18n.T(lang, "broadcast.duration", broadcast.FinishedAt.Sub(broadcast.StartedAt).Round(time.Second))
+	}
+	return text
+}
+
+// HandleAdminBroadcast shows the preview of the broadcast with the buttons to send or cancel it:
+// /admin_broadcast [md] <text>, or a reply with /admin_broadcast [md] to the text or the photo to send.
+// The last lines "Title | https://link" of the text become the buttons.
+func HandleAdminBroadcast(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	chatID := message.Chat.ID
+	text := strings.TrimSpace(message.CommandArguments())
+	parseMode := ""
+	if rest, ok := strings.CutPrefix(text, "md"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\n') {
+		parseMode = "Markdown"
+		text = strings.TrimSpace(rest)
+	}
+	photoFileID := ""
+	if reply := message.ReplyToMessage; reply != nil && text == "" {
+		text = reply.Text
+		if len(reply.Photo) > 0 {
+			photoFileID = reply.Photo[len(reply.Photo)-1].FileID
+			text = reply.Caption
+		}
+	}
+	if text == "" && photoFileID == "" {
+		SendText(bot, chatID, i18n.T(profile.Language, "admin.help"))
+		return
+	}
+
+	broadcast := objects.NewBroadcast(message.From.ID, chatID, text, parseMode, photoFileID)
+	if _, err := sendChattable(bot, chatID, broadcastMessage(&broadcast, chatID)); err != nil {
+		SendText(bot, chatID, i18n.T(profile.Language, "broadcast.preview_failed", err.Error()))
+		return
+	}
+	if err := services.Store.SaveBroadcast(context.Background(), broadcast); err != nil {
+		utils.Log("error saving broadcast: %v", err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, i18n.T(profile.Language, "broadcast.confirm"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(profile.Language, "broadcast.start"), broadcastCallbackPrefix+"start:"+broadcast.ID),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(profile.Language, "broadcast.cancel"), broadcastCallbackPrefix+"cancel:"+broadcast.ID),
+	))
+	SendMessage(bot, &msg)
+}
+
+// HandleAdminBroadcasts shows the latest broadcasts, the running ones with the button to stop them.
+func HandleAdminBroadcasts(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	list, err := services.Store.ListBroadcasts(context.Background(), broadcastListLimit)
+	if err != nil {
+		utils.Log("error listing broadcasts: %v", err)
+		SendError(bot, message.Chat.ID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	if len(list) == 0 {
+		SendText(bot, message.Chat.ID, i18n.T(profile.Language, "broadcast.empty"))
+		return
+	}
+	for _, broadcast := range list {
+		msg := tgbotapi.NewMessage(message.Chat.ID, formatBroadcast(profile.Language, broadcast))
+		if broadcast.Status == objects.BroadcastRunning {
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(profile.Language, "broadcast.stop"), broadcastCallbackPrefix+"stop:"+broadcast.ID),
+			))
+		}
+		SendMessage(bot, &msg)
+	}
+}
+
+// HandleBroadcastCallback handles admin:bc:start:<id>, admin:bc:cancel:<id> and admin:bc:stop:<id> buttons.
+func HandleBroadcastCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	ctx := context.Background()
+	chatID := callbackQuery.Message.Chat.ID
+	action, id, _ := strings.Cut(strings.TrimPrefix(callbackQuery.Data, broadcastCallbackPrefix), ":")
+	broadcast, err := services.Store.GetBroadcast(ctx, id)
+	if errors.Is(err, database.ErrNotFound) {
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "broadcast.not_found")))
+		return
+	}
+	if err != nil {
+		utils.Log("error get broadcast %s: %v", id, err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+	removeButtons := func(text string) {
+		edit := tgbotapi.NewEditMessageText(chatID, callbackQuery.Message.MessageID, text)
+		editChattable(bot, chatID, edit)
+	}
+
+	switch action {
+	case "start":
+		if broadcast.Status != objects.BroadcastDraft {
+			removeButtons(formatBroadcast(profile.Language, *broadcast))
+			return
+		}
+		broadcast.Status = objects.BroadcastRunning
+		broadcast.StartedAt = time.Now()
+		if err := services.Store.SaveBroadcast(ctx, *broadcast); err != nil {
+			utils.Log("error saving broadcast %s: %v", id, err)
+			SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+			return
+		}
+		// A broadcast not started because of the shutdown is resumed after the restart.
+		broadcasts.start(*broadcast)
+		removeButtons(i18n.T(profile.Language, "broadcast.started", broadcast.ID))
+	case "cancel":
+		if broadcast.Status == objects.BroadcastDraft {
+			broadcast.Status = objects.BroadcastCancelled
+			saveBroadcast(*broadcast)
+		}
+		removeButtons(formatBroadcast(profile.Language, *broadcast))
+	case "stop":
+		if !broadcasts.stop(id) {
+			removeButtons(formatBroadcast(profile.Language, *broadcast))
+			return
+		}
+		removeButtons(i18n.T(profile.Language, "broadcast.stopping", broadcast.ID))
+	}
+}
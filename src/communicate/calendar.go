@@ -0,0 +1,142 @@
+package communicate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	calendarYearsPage = 20
+	calendarYearsRow  = 5
+)
+
+// calendarOpenKeyboard is the button under a date question opening the calendar picker.
+func calendarOpenKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	start := time.Now().Year() - calendarYearsPage + 1
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "calendar.open"), fmt.Sprintf("cal:y:%d", start)),
+	))
+}
+
+// calendarYears shows the page of the years starting with start.
+func calendarYears(lang string, start int) tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for year := start; year < start+calendarYearsPage; year++ {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(year), fmt.Sprintf("cal:m:%d", year)))
+		if len(row) == calendarYearsRow {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	nav := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("«", fmt.Sprintf("cal:y:%d", start-calendarYearsPage)),
+	}
+	if start+calendarYearsPage <= time.Now().Year() {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("»", fmt.Sprintf("cal:y:%d", start+calendarYearsPage)))
+	}
+	keyboard = append(keyboard, nav)
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// calendarMonths shows the months of the year.
+func calendarMonths(lang string, year int) tgbotapi.InlineKeyboardMarkup {
+	names := strings.Fields(i18n.T(lang, "calendar.months"))
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for row := 0; row < 4; row++ {
+		var buttons []tgbotapi.InlineKeyboardButton
+		for month := row*3 + 1; month <= row*3+3; month++ {
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(names[month-1], fmt.Sprintf("cal:d:%d:%d", year, month)))
+		}
+		keyboard = append(keyboard, buttons)
+	}
+	// The page of the years ends with the current year, see calendarOpenKeyboard.
+	start := time.Now().Year() - calendarYearsPage + 1
+	for start > year {
+		start -= calendarYearsPage
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("« %d", year), fmt.Sprintf("cal:y:%d", start)),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// calendarDays shows the days of the month in weeks starting on Monday.
+func calendarDays(lang string, year int, month time.Month) tgbotapi.InlineKeyboardMarkup {
+	empty := func() tgbotapi.InlineKeyboardButton { return tgbotapi.NewInlineKeyboardButtonData(" ", "cal:none") }
+	var header []tgbotapi.InlineKeyboardButton
+	for _, weekday := range strings.Fields(i18n.T(lang, "calendar.weekdays")) {
+		header = append(header, tgbotapi.NewInlineKeyboardButtonData(weekday, "cal:none"))
+	}
+	keyboard := [][]tgbotapi.InlineKeyboardButton{header}
+
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	var week []tgbotapi.InlineKeyboardButton
+	for i := 0; i < (int(first.Weekday())+6)%7; i++ {
+		week = append(week, empty())
+	}
+	for day := first; day.Month() == month; day = day.AddDate(0, 0, 1) {
+		week = append(week, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(day.Day()), "cal:set:"+day.Format(time.DateOnly)))
+		if len(week) == 7 {
+			keyboard = append(keyboard, week)
+			week = nil
+		}
+	}
+	if len(week) > 0 {
+		for len(week) < 7 {
+			week = append(week, empty())
+		}
+		keyboard = append(keyboard, week)
+	}
+	names := strings.Fields(i18n.T(lang, "calendar.months"))
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("« %s %d", names[month-1], year), fmt.Sprintf("cal:m:%d", year)),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// HandleCalendarCallback handles the calendar picker buttons: cal:y:<first year>, cal:m:<year>,
+// cal:d:<year>:<month> open the pages and cal:set:<date> answers the date question.
+func HandleCalendarCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+	if !calendarStates[profile.State] {
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "calendar.expired")))
+		editChattable(bot, chatID, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+		return
+	}
+	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+
+	parts := strings.Split(callbackQuery.Data, ":")
+	var markup tgbotapi.InlineKeyboardMarkup
+	switch {
+	case len(parts) == 3 && parts[1] == "y":
+		start, _ := strconv.Atoi(parts[2])
+		markup = calendarYears(profile.Language, start)
+	case len(parts) == 3 && parts[1] == "m":
+		year, _ := strconv.Atoi(parts[2])
+		markup = calendarMonths(profile.Language, year)
+	case len(parts) == 4 && parts[1] == "d":
+		year, _ := strconv.Atoi(parts[2])
+		month, _ := strconv.Atoi(parts[3])
+		if month < 1 || month > 12 {
+			return
+		}
+		markup = calendarDays(profile.Language, year, time.Month(month))
+	case len(parts) == 3 && parts[1] == "set":
+		editChattable(bot, chatID, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+		state, _ := dialogs.Get(profile.State)
+		answerDialog(newDialogEnv(bot, chatID, profile), state, parts[2])
+		return
+	default:
+		return
+	}
+	editChattable(bot, chatID, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, markup))
+}
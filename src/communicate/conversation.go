@@ -0,0 +1,81 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"tgbot-numerologist/ai"
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// startConversation saves the delivered prediction, so the user can ask about it by replying to the message.
+func startConversation(profile *objects.Profile, predictionType string, messages []ai.Message, answer string, messageIDs []int) {
+	conversation := objects.NewConversation(profile.UserID, predictionType, messages, answer, messageIDs...)
+	if err := services.Store.SaveConversation(context.Background(), conversation, services.FollowUp.TTL); err != nil {
+		utils.Log("error saving conversation of user %d: %v", profile.UserID, err)
+	}
+}
+
+// refundFollowUp returns the cost of the question which was not answered.
+func refundFollowUp(ctx context.Context, profile *objects.Profile) {
+	if err := services.Store.RefundPart(ctx, profile.UserID, services.FollowUp.Cost); err != nil {
+		utils.Log("error on refund quota: %s", err.Error())
+	}
+}
+
+// HandleFollowUp continues the conversation about the prediction the user replied to.
+func HandleFollowUp(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	conversation, err := services.Store.GetConversation(ctx, profile.UserID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		utils.Log("error get conversation: %v", err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	if conversation == nil || !conversation.HasMessage(message.ReplyToMessage.MessageID) {
+		SendText(bot, chatID, i18n.T(profile.Language, "conversation.expired"))
+		return
+	}
+	question := strings.TrimSpace(message.Text)
+	if question == "" {
+		SendText(bot, chatID, i18n.T(profile.Language, "conversation.text_only"))
+		return
+	}
+
+	// The cost is a part of a prediction, the whole predictions are taken from the quota when the credit is spent.
+	err = services.Store.ReservePart(ctx, profile.UserID, services.FollowUp.Cost)
+	if errors.Is(err, database.ErrQuotaExhausted) {
+		SendText(bot, chatID, i18n.T(profile.Language, "conversation.no_quota"))
+		return
+	}
+	if err != nil {
+		utils.Log("error on reserve quota: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+
+	conversation.AddQuestion(question)
+	conversation.Trim(services.FollowUp.TokenBudget)
+	aiCtx, cancel := context.WithTimeout(ctx, predictionTimeout)
+	defer cancel()
+	messageIDs, answer, err := SendStream(bot, chatID, i18n.T(profile.Language, "conversation.waiting"), func(onDelta func(string)) (string, error) {
+		return services.Provider.StreamMessage(aiCtx, conversation.Messages, onDelta)
+	})
+	if err != nil {
+		utils.Log("Err getting ai follow-up response: %s", err.Error())
+		refundFollowUp(ctx, profile)
+		SendError(bot, chatID, aiError(profile.Language, err))
+		return
+	}
+	conversation.AddAnswer(answer, messageIDs...)
+	if err := services.Store.SaveConversation(ctx, *conversation, services.FollowUp.TTL); err != nil {
+		utils.Log("error saving conversation of user %d: %v", profile.UserID, err)
+	}
+}
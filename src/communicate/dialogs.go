@@ -0,0 +1,385 @@
+package communicate
+
+import (
+	"errors"
+	"strings"
+
+	"tgbot-numerologist/fsm"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/predictions"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// statePredictionInput asks the data of the prediction type kept in Profile.StateArg.
+	statePredictionInput  = "predict"
+	statePartnerName      = "partner_name"
+	statePartnerBirthDate = "partner_birthdate"
+	wizardStatePrefix     = "wizard_"
+)
+
+// dialogEnv is what the dialog states work with.
+type dialogEnv struct {
+	bot     *tgbotapi.BotAPI
+	chatID  int64
+	profile *objects.Profile
+	// arg is the parameter of the state, it stays set in Done and Cancel after the profile state is cleared.
+	arg string
+	// value is the answer parsed by Apply for Done.
+	value   string
+	partner *objects.Partner
+}
+
+type dialogState = fsm.State[*dialogEnv]
+
+// calendarStates ask a date, their question has the button opening the calendar picker.
+var calendarStates = map[string]bool{}
+
+var dialogs = newDialogs()
+
+func newDialogs() *fsm.Machine[*dialogEnv] {
+	m := fsm.New[*dialogEnv]()
+
+	for _, field := range objects.ProfileFields {
+		if field.IsDate() {
+			calendarStates[objects.EditFieldPrefix+field.ID] = true
+			calendarStates[wizardStatePrefix+field.ID] = true
+		}
+		m.Add(objects.EditFieldPrefix+field.ID, dialogState{
+			Prompt: fieldPrompt(field),
+			Apply:  applyField(field),
+			Done: func(env *dialogEnv) {
+				SendText(env.bot, env.chatID, i18n.T(env.profile.Language, "profile.updated"))
+				sendProfile(env.bot, env.chatID, env.profile, "")
+			},
+			Cancel: func(env *dialogEnv) {
+				sendProfile(env.bot, env.chatID, env.profile, i18n.T(env.profile.Language, "edit.cancelled"))
+			},
+		})
+	}
+
+	// The onboarding wizard asks the required fields first, the optional ones can be skipped.
+	var wizard []objects.ProfileField
+	for _, required := range []bool{true, false} {
+		for _, field := range objects.ProfileFields {
+			if field.Required == required {
+				wizard = append(wizard, field)
+			}
+		}
+	}
+	next := ""
+	for i := len(wizard) - 1; i >= 0; i-- {
+		field := wizard[i]
+		prompt := fieldPrompt(field)
+		if !field.Required {
+			prompt = func(env *dialogEnv) string {
+				return fieldPrompt(field)(env) + "\n" + i18n.T(env.profile.Language, "wizard.skip_hint")
+			}
+		}
+		m.Add(wizardStatePrefix+field.ID, dialogState{
+			Prompt:    prompt,
+			Apply:     applyField(field),
+			Next:      next,
+			Skippable: !field.Required,
+			Done: func(env *dialogEnv) {
+				sendProfile(env.bot, env.chatID, env.profile, i18n.T(env.profile.Language, "wizard.done"))
+			},
+			Cancel: func(env *dialogEnv) {
+				SendText(env.bot, env.chatID, i18n.T(env.profile.Language, "wizard.cancelled"))
+			},
+		})
+		next = wizardStatePrefix + field.ID
+	}
+
+	m.Add(statePredictionInput, dialogState{
+		Prompt: func(env *dialogEnv) string {
+			t, _ := predictions.Get(env.arg)
+			return i18n.T(env.profile.Language, t.InputPrompt)
+		},
+		Apply: func(env *dialogEnv, text string) error {
+			t, ok := predictions.Get(env.arg)
+			if !ok || t.ParseInput == nil {
+				return errors.New("unknown prediction type " + env.arg)
+			}
+			value, err := t.ParseInput(text)
+			if err != nil {
+				return fsm.NewInputError("predictions.wrong_input")
+			}
+			env.value = value
+			return nil
+		},
+		Done: func(env *dialogEnv) {
+			t, _ := predictions.Get(env.arg)
+			runPrediction(env.bot, env.chatID, env.profile, t, env.value, nil)
+		},
+	})
+
+	calendarStates[statePartnerBirthDate] = true
+	m.Add(statePartnerBirthDate, dialogState{
+		Prompt: func(env *dialogEnv) string { return i18n.T(env.profile.Language, "partners.enter_date") },
+		Apply: func(env *dialogEnv, text string) error {
+			birthDate, err := objects.ParseDate(text)
+			if err != nil {
+				return inputError(env.profile.Language, err)
+			}
+			if err := objects.Validate(&objects.Partner{}, "BirthDate", birthDate); err != nil {
+				return inputError(env.profile.Language, err)
+			}
+			draft := env.profile.PartnerDraft
+			if draft == nil {
+				draft = &objects.Partner{}
+			}
+			partner := objects.NewPartner(draft.Name, draft.Surname, birthDate)
+			env.profile.SavePartner(partner)
+			env.profile.PartnerDraft = nil
+			env.partner = &partner
+			return nil
+		},
+		Done: func(env *dialogEnv) {
+			SendText(env.bot, env.chatID, i18n.T(env.profile.Language, "partners.saved"))
+			runCompatibility(env.bot, env.chatID, env.profile, *env.partner)
+		},
+	})
+	m.Add(statePartnerName, dialogState{
+		Prompt: func(env *dialogEnv) string { return i18n.T(env.profile.Language, "partners.enter_name") },
+		Apply: func(env *dialogEnv, text string) error {
+			fields := strings.Fields(text)
+			draft := &objects.Partner{Name: fields[0], Surname: strings.Join(fields[1:], " ")}
+			if err := objects.Validate(draft, "Name", draft.Name); err != nil {
+				return inputError(env.profile.Language, err)
+			}
+			if err := objects.Validate(draft, "Surname", draft.Surname); err != nil {
+				return inputError(env.profile.Language, err)
+			}
+			env.profile.PartnerDraft = draft
+			return nil
+		},
+		Next: statePartnerBirthDate,
+	})
+	return m
+}
+
+func fieldPrompt(field objects.ProfileField) func(env *dialogEnv) string {
+	return func(env *dialogEnv) string {
+		return i18n.T(env.profile.Language, "profile.enter_"+field.ID)
+	}
+}
+
+func applyField(field objects.ProfileField) func(env *dialogEnv, text string) error {
+	return func(env *dialogEnv, text string) error {
+		return inputError(env.profile.Language, field.Set(env.profile, text))
+	}
+}
+
+// inputError converts the parse and validation errors to the messages shown to the user.
+func inputError(lang string, err error) error {
+	var validationErr *objects.ValidationError
+	var ambiguousErr *objects.AmbiguousDateError
+	var ambiguousPlaceErr *objects.AmbiguousPlaceError
+	switch {
+	case errors.Is(err, objects.ErrWrongDate):
+		return fsm.NewInputError("profile.wrong_date")
+	case errors.Is(err, objects.ErrWrongTime):
+		return fsm.NewInputError("profile.wrong_time")
+	case errors.Is(err, objects.ErrUnknownPlace):
+		return fsm.NewInputError("profile.unknown_place")
+	case errors.As(err, &ambiguousPlaceErr):
+		return fsm.NewInputError("profile.ambiguous_place", ambiguousPlaceErr.Names(lang))
+	case errors.As(err, &ambiguousErr):
+		return fsm.NewInputError("profile.ambiguous_date",
+			ambiguousErr.First.Format("02.01.2006"), ambiguousErr.Second.Format("02.01.2006"))
+	case errors.As(err, &validationErr):
+		return fsm.NewInputError(validationErr.Message, validationErr.Args...)
+	}
+	return err
+}
+
+func sendProfile(bot *tgbotapi.BotAPI, chatID int64, profile *objects.Profile, title string) {
+	msg := tgbotapi.NewMessage(chatID, title+profile.FormatProfileMessage())
+	msg.ReplyMarkup = profile.GetKeyboard()
+	msg.ParseMode = "Markdown"
+	SendMessage(bot, &msg)
+}
+
+func newDialogEnv(bot *tgbotapi.BotAPI, chatID int64, profile *objects.Profile) *dialogEnv {
+	return &dialogEnv{bot: bot, chatID: chatID, profile: profile, arg: profile.StateArg}
+}
+
+func askState(env *dialogEnv) {
+	state, ok := dialogs.Get(env.profile.State)
+	if !ok || state.Prompt == nil {
+		return
+	}
+	if !calendarStates[env.profile.State] {
+		SendText(env.bot, env.chatID, state.Prompt(env))
+		return
+	}
+	msg := tgbotapi.NewMessage(env.chatID, state.Prompt(env))
+	msg.ReplyMarkup = calendarOpenKeyboard(env.profile.Language)
+	SendMessage(env.bot, &msg)
+}
+
+// setDialogState moves the profile to the state, the argument is cleared when the dialog is finished.
+func setDialogState(p *objects.Profile, state string) {
+	p.State = state
+	if state == "" {
+		p.StateArg = ""
+	}
+}
+
+// startDialog enters the state and asks its question, arg is kept in Profile.StateArg.
+func startDialog(bot *tgbotapi.BotAPI, chatID int64, profile *objects.Profile, state, arg string) {
+	if _, ok := dialogs.Get(state); !ok {
+		utils.Log("unknown dialog state %s", state)
+		return
+	}
+	utils.Log("enter dialog state: %s", state)
+	err := updateProfile(profile, func(p *objects.Profile) error {
+		p.State = state
+		p.StateArg = arg
+		return nil
+	})
+	if err != nil {
+		utils.Log("error on save profile when enter dialog: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	SendText(bot, chatID, i18n.T(profile.Language, "edit.stop_hint"))
+	askState(newDialogEnv(bot, chatID, profile))
+}
+
+// continueDialog asks the question of the next state or finishes the dialog in the current one.
+func continueDialog(env *dialogEnv, current dialogState, next string) {
+	if next != "" {
+		askState(env)
+		return
+	}
+	if current.Done != nil {
+		current.Done(env)
+	}
+}
+
+// HandleDialogInput applies the answer in the current dialog state.
+func HandleDialogInput(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	env := newDialogEnv(bot, message.Chat.ID, profile)
+	state, ok := dialogs.Get(profile.State)
+	if !ok {
+		utils.Log("unknown dialog state %s, reset", profile.State)
+		err := updateProfile(profile, func(p *objects.Profile) error {
+			setDialogState(p, "")
+			return nil
+		})
+		if err != nil {
+			utils.Log("error on save profile when reset dialog: %s", err.Error())
+		}
+		SendCommon(bot, message, profile.Language)
+		return
+	}
+	text := strings.TrimSpace(message.Text)
+	if text == "" {
+		askState(env)
+		return
+	}
+	answerDialog(env, state, text)
+}
+
+// answerDialog applies the answer typed or picked by the user and moves to the next state,
+// a wrong answer is explained and the question is repeated. The answer is applied to the stored
+// profile and saved together with the next state.
+func answerDialog(env *dialogEnv, state dialogState, text string) {
+	profile := env.profile
+	current := profile.State
+	var next string
+	err := updateProfile(profile, func(p *objects.Profile) error {
+		applied := *env
+		applied.profile = p
+		var err error
+		if next, err = dialogs.Answer(current, &applied, text); err != nil {
+			return err
+		}
+		env.value, env.partner = applied.value, applied.partner
+		setDialogState(p, next)
+		return nil
+	})
+	var inputErr *fsm.InputError
+	if errors.As(err, &inputErr) {
+		SendText(env.bot, env.chatID, i18n.T(profile.Language, inputErr.Message, inputErr.Args...))
+		askState(env)
+		return
+	}
+	if err != nil {
+		utils.Log("error in dialog state %s: %v", current, err)
+		SendError(env.bot, env.chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	continueDialog(env, state, next)
+}
+
+// HandleSkip leaves the optional step of the dialog without an answer.
+func HandleSkip(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	env := newDialogEnv(bot, message.Chat.ID, profile)
+	if profile.State == "" {
+		SendText(bot, env.chatID, i18n.T(profile.Language, "edit.not_editing"))
+		return
+	}
+	state, _ := dialogs.Get(profile.State)
+	next, ok := dialogs.Skip(profile.State)
+	if !ok {
+		SendText(bot, env.chatID, i18n.T(profile.Language, "wizard.cannot_skip"))
+		return
+	}
+	err := updateProfile(profile, func(p *objects.Profile) error {
+		setDialogState(p, next)
+		return nil
+	})
+	if err != nil {
+		utils.Log("error on save profile when skip: %s", err.Error())
+		SendError(bot, env.chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	continueDialog(env, state, next)
+}
+
+// HandleStop cancels the current dialog.
+func HandleStop(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	chatID := message.Chat.ID
+	if profile.State == "" {
+		SendText(bot, chatID, i18n.T(profile.Language, "edit.not_editing"))
+		return
+	}
+	state, _ := dialogs.Get(profile.State)
+	env := newDialogEnv(bot, chatID, profile)
+	err := updateProfile(profile, func(p *objects.Profile) error {
+		setDialogState(p, "")
+		p.PartnerDraft = nil
+		return nil
+	})
+	if err != nil {
+		utils.Log("error on save profile when edit: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	if state.Cancel == nil {
+		SendText(bot, chatID, i18n.T(profile.Language, "dialog.cancelled"))
+		return
+	}
+	state.Cancel(env)
+}
+
+// HandleStart greets the user and starts the onboarding wizard when the profile is not filled.
+func HandleStart(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	HandleIntro(bot, message, profile)
+	if profile.IsFilled() || profile.State != "" {
+		return
+	}
+	SendText(bot, message.Chat.ID, i18n.T(profile.Language, "wizard.start"))
+	for _, field := range objects.ProfileFields {
+		if field.Required {
+			startDialog(bot, message.Chat.ID, profile, wizardStatePrefix+field.ID, "")
+			return
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var ErrDispatcherStopped = errors.New("dispatcher is stopped")
+
+// Dispatcher handles updates of different users in parallel with a fixed number of workers.
+// Updates of one user are handled strictly one after another in the order of submission.
+// The number of queued updates is bounded, Submit blocks when the queue is full.
+type Dispatcher struct {
+	handle func(tgbotapi.Update)
+
+	// slots limits the number of queued and in-flight updates.
+	slots chan struct{}
+	// ready contains users who have pending updates and are not handled by any worker.
+	ready chan int64
+
+	mu      sync.Mutex
+	pending map[int64][]tgbotapi.Update
+	stopped bool
+
+	queued  sync.WaitGroup
+	workers sync.WaitGroup
+}
+
+func NewDispatcher(workers, queueSize int, handle func(tgbotapi.Update)) *Dispatcher {
+	d := &Dispatcher{
+		handle:  handle,
+		slots:   make(chan struct{}, queueSize),
+		ready:   make(chan int64, queueSize),
+		pending: make(map[int64][]tgbotapi.Update),
+	}
+	d.workers.Add(workers)
+	for range workers {
+		go d.work()
+	}
+	return d
+}
+
+// updateKey returns the identifier used to order updates, usually the sender id.
+func updateKey(update tgbotapi.Update) int64 {
+	// Pre-checkout queries must be answered within 10 seconds and do not depend on
+	// other updates, so they are never queued behind a slow update of the same user.
+	if update.PreCheckoutQuery != nil {
+		return 0
+	}
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	return 0
+}
+
+// Submit queues the update. It blocks while the queue is full until ctx is done.
+func (d *Dispatcher) Submit(ctx context.Context, update tgbotapi.Update) error {
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	key := updateKey(update)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		<-d.slots
+		return ErrDispatcherStopped
+	}
+	d.queued.Add(1)
+	queue, active := d.pending[key]
+	d.pending[key] = append(queue, update)
+	if !active {
+		// Never blocks: every user in ready holds at least one slot.
+		d.ready <- key
+	}
+	return nil
+}
+
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+	for key := range d.ready {
+		d.mu.Lock()
+		update := d.pending[key][0]
+		d.mu.Unlock()
+
+		d.safeHandle(update)
+
+		d.mu.Lock()
+		queue := d.pending[key][1:]
+		if len(queue) == 0 {
+			delete(d.pending, key)
+		} else {
+			d.pending[key] = queue
+			// Put the user back to the end of the line so others are not starved.
+			d.ready <- key
+		}
+		d.mu.Unlock()
+
+		<-d.slots
+		d.queued.Done()
+	}
+}
+
+// safeHandle keeps the worker alive when a handler panics.
+func (d *Dispatcher) safeHandle(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.Log("panic while handling update %d: %v", update.UpdateID, r)
+		}
+	}()
+	d.handle(update)
+}
+
+// Stop rejects new updates and waits until the queued ones are handled or ctx is done.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	d.stopped = true
+	d.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		d.queued.Wait()
+		close(d.ready)
+		d.workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
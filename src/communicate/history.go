@@ -0,0 +1,149 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/predictions"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	historyPageSize     = 5
+	historyPreviewRunes = 60
+)
+
+// savePrediction adds the delivered prediction to the history of the user.
+func savePrediction(profile *objects.Profile, predictionType, text string) {
+	prediction := objects.NewPrediction(profile.UserID, predictionType, profile.Hash(), services.Provider.Model(), text)
+	if err := services.Store.AddPrediction(context.Background(), prediction); err != nil {
+		utils.Log("error saving prediction of user %d: %v", profile.UserID, err)
+	}
+}
+
+func previewText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= historyPreviewRunes {
+		return text
+	}
+	return string([]rune(text)[:historyPreviewRunes]) + "…"
+}
+
+// historyPage renders the page of the history with buttons to open, delete and navigate.
+func historyPage(profile *objects.Profile, page int) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	items, total, err := services.Store.ListPredictions(context.Background(), profile.UserID, page*historyPageSize, historyPageSize)
+	if err != nil {
+		return "", nil, err
+	}
+	if total == 0 {
+		return i18n.T(profile.Language, "history.empty"), nil, nil
+	}
+	pages := (total + historyPageSize - 1) / historyPageSize
+	if page >= pages && page > 0 {
+		return historyPage(profile, pages-1)
+	}
+
+	text := i18n.T(profile.Language, "history.page", page+1, pages)
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for i, prediction := range items {
+		n := page*historyPageSize + i + 1
+		text += fmt.Sprintf("%d. %s, %s\n%s\n\n", n, prediction.CreatedAt.Format("02.01.2006 15:04"),
+			predictions.Title(profile.Language, prediction.Type), previewText(prediction.Text))
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(profile.Language, "history.show", n), "history:show:"+prediction.ID),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(profile.Language, "history.delete", n), fmt.Sprintf("history:del:%d:%s", page, prediction.ID)),
+		))
+	}
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.T(profile.Language, "history.back"), fmt.Sprintf("history:page:%d", page-1)))
+	}
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.T(profile.Language, "history.forward"), fmt.Sprintf("history:page:%d", page+1)))
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	return text, &markup, nil
+}
+
+func HandleHistory(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	text, markup, err := historyPage(profile, 0)
+	if err != nil {
+		utils.Log("error list predictions: %v", err)
+		SendError(bot, message.Chat.ID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	if markup != nil {
+		msg.ReplyMarkup = *markup
+	}
+	SendMessage(bot, &msg)
+}
+
+// editHistoryPage replaces the history message with the page.
+func editHistoryPage(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile, page int) {
+	chatID := callbackQuery.Message.Chat.ID
+	text, markup, err := historyPage(profile, page)
+	if err != nil {
+		utils.Log("error list predictions: %v", err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(chatID, callbackQuery.Message.MessageID, text)
+	edit.ReplyMarkup = markup
+	editChattable(bot, chatID, edit)
+}
+
+// HandleHistoryCallback handles history:page:<page>, history:show:<id> and history:del:<page>:<id> buttons.
+func HandleHistoryCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	ctx := context.Background()
+	chatID := callbackQuery.Message.Chat.ID
+	parts := strings.Split(callbackQuery.Data, ":")
+	if len(parts) < 3 {
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+		return
+	}
+
+	switch parts[1] {
+	case "page":
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+		page, _ := strconv.Atoi(parts[2])
+		editHistoryPage(bot, callbackQuery, profile, max(page, 0))
+	case "show":
+		prediction, err := services.Store.GetPrediction(ctx, profile.UserID, parts[2])
+		if errors.Is(err, database.ErrNotFound) {
+			bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "history.not_found")))
+			return
+		}
+		if err != nil {
+			utils.Log("error get prediction: %v", err)
+			SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+			return
+		}
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+		SendMarkdown(bot, chatID, prediction.Text)
+	case "del":
+		if len(parts) < 4 {
+			return
+		}
+		if err := services.Store.DeletePrediction(ctx, profile.UserID, parts[3]); err != nil {
+			utils.Log("error delete prediction: %v", err)
+			SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+			return
+		}
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "history.deleted")))
+		page, _ := strconv.Atoi(parts[2])
+		editHistoryPage(bot, callbackQuery, profile, max(page, 0))
+	}
+}
@@ -0,0 +1,42 @@
+package communicate
+
+import (
+	"strings"
+
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleLanguage shows the languages the bot speaks.
+func HandleLanguage(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, language := range i18n.Languages {
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(language.Title, "lang:"+language.Code),
+		))
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, i18n.T(profile.Language, "language.choose"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	SendMessage(bot, &msg)
+}
+
+// HandleLanguageCallback saves the language chosen with lang:<code> button.
+func HandleLanguageCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	chatID := callbackQuery.Message.Chat.ID
+	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+	language := i18n.Supported(strings.TrimPrefix(callbackQuery.Data, "lang:"))
+	err := updateProfile(profile, func(p *objects.Profile) error {
+		p.Language = language
+		return nil
+	})
+	if err != nil {
+		utils.Log("error on save profile when change language: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(chatID, callbackQuery.Message.MessageID, i18n.T(profile.Language, "language.changed"))
+	editChattable(bot, chatID, edit)
+}
@@ -0,0 +1,189 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/predictions"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	shareStartPrefix  = "share_"
+	compatibilityType = "compatibility"
+)
+
+// errPartnerNotShared is returned for a linked partner whose owner disabled the share link.
+var errPartnerNotShared = errors.New("partner profile is not shared")
+
+func partnersKeyboard(profile *objects.Profile) tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, partner := range profile.Partners {
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(partner.Title(), "partner:use:"+partner.ID),
+			tgbotapi.NewInlineKeyboardButtonData("🗑", "partner:del:"+partner.ID),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(profile.Language, "partners.add"), "partner:new"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+func sendPartnersMenu(bot *tgbotapi.BotAPI, chatID int64, profile *objects.Profile) {
+	msg := tgbotapi.NewMessage(chatID, i18n.T(profile.Language, "partners.menu"))
+	msg.ReplyMarkup = partnersKeyboard(profile)
+	SendMessage(bot, &msg)
+}
+
+// resolvePartner reads the partner linked to a bot user from the current profile. The partner is
+// not available when the owner stopped sharing the profile, the saved data is never used instead.
+func resolvePartner(ctx context.Context, partner objects.Partner) (objects.Partner, error) {
+	if partner.UserID == 0 {
+		return partner, nil
+	}
+	owner, err := services.Store.GetProfile(ctx, partner.UserID)
+	if errors.Is(err, database.ErrNotFound) {
+		return partner, errPartnerNotShared
+	}
+	if err != nil {
+		return partner, err
+	}
+	if !partner.SharedBy(owner) || owner.Name == "" || owner.BirthDate.IsZero() {
+		return partner, errPartnerNotShared
+	}
+	partner.Name = owner.Name
+	partner.Surname = owner.Surname
+	partner.BirthDate = owner.BirthDate
+	return partner, nil
+}
+
+func runCompatibility(bot *tgbotapi.BotAPI, chatID int64, profile *objects.Profile, partner objects.Partner) {
+	t, ok := predictions.Get(compatibilityType)
+	if !ok {
+		return
+	}
+	partner, err := resolvePartner(context.Background(), partner)
+	if errors.Is(err, errPartnerNotShared) {
+		SendText(bot, chatID, i18n.T(profile.Language, "partners.not_shared"))
+		return
+	}
+	if err != nil {
+		utils.Log("error resolving partner of user %d: %v", profile.UserID, err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	runPrediction(bot, chatID, profile, t, "", &partner)
+}
+
+// HandlePartnerCallback handles partner:use:<id>, partner:del:<id> and partner:new buttons.
+func HandlePartnerCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	chatID := callbackQuery.Message.Chat.ID
+	action, id, _ := strings.Cut(strings.TrimPrefix(callbackQuery.Data, "partner:"), ":")
+
+	switch action {
+	case "use":
+		partner, ok := profile.FindPartner(id)
+		if !ok {
+			bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "partners.not_found")))
+			return
+		}
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+		runCompatibility(bot, chatID, profile, partner)
+	case "del":
+		err := updateProfile(profile, func(p *objects.Profile) error {
+			p.DeletePartner(id)
+			return nil
+		})
+		if err != nil {
+			utils.Log("error on save profile when delete partner: %s", err.Error())
+			SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+			return
+		}
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "partners.deleted")))
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, callbackQuery.Message.MessageID, i18n.T(profile.Language, "partners.menu"), partnersKeyboard(profile))
+		editChattable(bot, chatID, edit)
+	case "new":
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "edit.waiting")))
+		startDialog(bot, chatID, profile, statePartnerName, "")
+	}
+}
+
+// HandleShare creates the link other users open to add the profile as a partner, "/share off" disables it.
+func HandleShare(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	chatID := message.Chat.ID
+	if strings.TrimSpace(message.CommandArguments()) == "off" {
+		err := updateProfile(profile, func(p *objects.Profile) error {
+			p.ShareToken = ""
+			return nil
+		})
+		if err != nil {
+			utils.Log("error on save profile when share: %s", err.Error())
+			SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+			return
+		}
+		SendText(bot, chatID, i18n.T(profile.Language, "share.off"))
+		return
+	}
+	if profile.Name == "" || profile.BirthDate.IsZero() {
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrFillRequired))
+		return
+	}
+	if profile.ShareToken == "" {
+		token := objects.NewShareToken()
+		err := updateProfile(profile, func(p *objects.Profile) error {
+			if p.ShareToken == "" {
+				p.ShareToken = token
+			}
+			return nil
+		})
+		if err != nil {
+			utils.Log("error on save profile when share: %s", err.Error())
+			SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+			return
+		}
+	}
+	link := fmt.Sprintf("https://t.me/%s?start=%s%d_%s", bot.Self.UserName, shareStartPrefix, profile.UserID, profile.ShareToken)
+	SendText(bot, chatID, i18n.T(profile.Language, "share.link", link))
+}
+
+// HandleSharedStart adds the owner of the share link opened with /start as a partner.
+func HandleSharedStart(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	ownerStr, token, _ := strings.Cut(strings.TrimPrefix(message.CommandArguments(), shareStartPrefix), "_")
+	ownerID, err := strconv.ParseInt(ownerStr, 10, 64)
+	if err != nil || token == "" {
+		HandleIntro(bot, message, profile)
+		return
+	}
+	if ownerID == profile.UserID {
+		SendText(bot, chatID, i18n.T(profile.Language, "share.own_link"))
+		return
+	}
+	owner, err := services.Store.GetProfile(ctx, ownerID)
+	if err != nil || owner.ShareToken == "" || owner.ShareToken != token || owner.Name == "" || owner.BirthDate.IsZero() {
+		SendText(bot, chatID, i18n.T(profile.Language, "share.invalid_link"))
+		return
+	}
+	partner := objects.NewLinkedPartner(owner)
+	err = updateProfile(profile, func(p *objects.Profile) error {
+		p.SavePartner(partner)
+		return nil
+	})
+	if err != nil {
+		utils.Log("error on save profile when add shared partner: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	SendText(bot, chatID, i18n.T(profile.Language, "partners.added", partner.Title()))
+	sendPartnersMenu(bot, chatID, profile)
+}
@@ -0,0 +1,246 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func findPackage(id string) (objects.QuotaPackage, bool) {
+	for _, pkg := range services.Packages {
+		if pkg.ID == id {
+			return pkg, true
+		}
+	}
+	return objects.QuotaPackage{}, false
+}
+
+// sendPaymentMessage shows the quota of the user with the buttons to buy more.
+func sendPaymentMessage(bot *tgbotapi.BotAPI, profile *objects.Profile) {
+	quota, err := services.Store.GetQuota(context.Background(), profile.UserID)
+	if err != nil {
+		utils.Log("error get quota: %s", err.Error())
+		SendError(bot, profile.ChatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	msgText := i18n.T(profile.Language, "payment.status", quota.Available, quota.Predictions)
+	msg := tgbotapi.NewMessage(profile.ChatID, msgText)
+	msg.ReplyMarkup = profile.GetPaymentKeyboard(services.Packages)
+	msg.ParseMode = "Markdown"
+
+	SendMessage(bot, &msg)
+}
+
+func HandlePayment(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	sendPaymentMessage(bot, profile)
+}
+
+// HandleBuyButton sends the invoice in Telegram Stars for the chosen package.
+func HandleBuyButton(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	chatID := callbackQuery.Message.Chat.ID
+	pkg, ok := findPackage(strings.TrimPrefix(callbackQuery.Data, "buy:"))
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "payment.package_unavailable")))
+		sendPaymentMessage(bot, profile)
+		return
+	}
+	invoice := tgbotapi.InvoiceConfig{
+		BaseChat:    tgbotapi.BaseChat{ChatID: chatID},
+		Title:       pkg.Title(profile.Language),
+		Description: i18n.T(profile.Language, "payment.invoice_description", i18n.Plural(profile.Language, "predictions", pkg.Quota)),
+		Payload:     pkg.InvoicePayload(),
+		// Payments in Telegram Stars do not need a payment provider.
+		ProviderToken:       "",
+		Currency:            objects.StarsCurrency,
+		Prices:              []tgbotapi.LabeledPrice{{Label: pkg.Title(profile.Language), Amount: pkg.Stars}},
+		SuggestedTipAmounts: []int{},
+	}
+	if _, err := sendChattable(bot, chatID, invoice); err != nil {
+		utils.Log("error sending invoice: %v", err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+}
+
+// HandlePaymentsButton shows the payment ledger of the user.
+func HandlePaymentsButton(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	chatID := callbackQuery.Message.Chat.ID
+	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+	payments, err := services.Store.ListPayments(context.Background(), profile.UserID)
+	if err != nil {
+		utils.Log("error list payments: %v", err)
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	if len(payments) == 0 {
+		SendText(bot, chatID, i18n.T(profile.Language, "payment.no_payments"))
+		return
+	}
+	msgText := i18n.T(profile.Language, "payment.history")
+	for _, payment := range payments {
+		msgText += i18n.T(profile.Language, "payment.history_row", payment.CreatedAt.Format("02.01.2006 15:04"),
+			i18n.Plural(profile.Language, "predictions", payment.Quota), payment.Amount, payment.Currency)
+	}
+	SendText(bot, chatID, msgText)
+}
+
+// HandlePreCheckout confirms that the invoice is still valid and the user is not banned.
+// Telegram waits for the answer only 10 seconds.
+func HandlePreCheckout(bot *tgbotapi.BotAPI, query *tgbotapi.PreCheckoutQuery) {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: true}
+	packageID, quota, err := objects.ParseInvoicePayload(query.InvoicePayload)
+	pkg, ok := findPackage(packageID)
+	profile, profileErr := services.Store.GetProfile(context.Background(), query.From.ID)
+	switch {
+	case profileErr != nil:
+		utils.Log("pre checkout from %d: get profile: %v", query.From.ID, profileErr)
+		answer.OK = false
+	case profile.Banned && !isAdmin(profile.UserID):
+		utils.Log("pre checkout from banned user %d", query.From.ID)
+		answer.OK = false
+	case err != nil:
+		utils.Log("pre checkout from %d: %v", query.From.ID, err)
+		answer.OK = false
+	case !ok || pkg.Quota != quota || pkg.Stars != query.TotalAmount || query.Currency != objects.StarsCurrency:
+		utils.Log("pre checkout from %d: package %s changed", query.From.ID, packageID)
+		answer.OK = false
+	}
+	if !answer.OK {
+		answer.ErrorMessage = i18n.T(i18n.Detect(query.From.LanguageCode), "payment.pre_checkout_failed")
+	}
+	if _, err := bot.Request(answer); err != nil {
+		utils.Log("error answering pre checkout query: %v", err)
+	}
+}
+
+// pendingPaymentsPageSize is the number of pending payments credited in one run.
+const pendingPaymentsPageSize = 100
+
+// HandleSuccessfulPayment records the payment and credits the quota. Telegram may deliver the same payment twice,
+// the charge id makes crediting idempotent. A payment which is not credited stays pending for CreditPendingPayments,
+// when the store is not available it is kept in the local payment journal.
+func HandleSuccessfulPayment(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	successful := message.SuccessfulPayment
+	packageID, quota, err := objects.ParseInvoicePayload(successful.InvoicePayload)
+	if err != nil {
+		utils.Log("successful payment %s of user %d: %v", successful.TelegramPaymentChargeID, profile.UserID, err)
+		SendError(bot, message.Chat.ID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	payment := objects.Payment{
+		ChargeID:         successful.TelegramPaymentChargeID,
+		ProviderChargeID: successful.ProviderPaymentChargeID,
+		UserID:           profile.UserID,
+		PackageID:        packageID,
+		Quota:            quota,
+		Amount:           successful.TotalAmount,
+		Currency:         successful.Currency,
+		CreatedAt:        time.Now(),
+	}
+	journaled := false
+	if err := services.Store.RecordPayment(context.Background(), payment); err != nil {
+		utils.Log("error recording payment %s of user %d, it is added to the journal: %v", payment.ChargeID, profile.UserID, err)
+		if err := journalPayment(payment); err != nil {
+			utils.Log("ERROR payment is lost, credit it manually: %s: %v", formatLostPayment(payment), err)
+			SendText(bot, message.Chat.ID, i18n.T(profile.Language, "payment.not_recorded", payment.ChargeID))
+			return
+		}
+		journaled = true
+	}
+	credited, err := services.Store.CreditPayment(context.Background(), payment)
+	if err != nil {
+		utils.Log("error crediting payment %s of user %d, it is left pending: %v", payment.ChargeID, profile.UserID, err)
+		SendText(bot, message.Chat.ID, i18n.T(profile.Language, "payment.pending"))
+		return
+	}
+	if journaled {
+		removeJournaledPayment(payment.ChargeID)
+	}
+	if !credited {
+		utils.Log("payment %s of user %d is already credited", payment.ChargeID, profile.UserID)
+		return
+	}
+	utils.Log("payment %s of user %d: credited %d predictions", payment.ChargeID, profile.UserID, quota)
+	sendPaymentThanks(bot, profile, payment)
+}
+
+func journalPayment(payment objects.Payment) error {
+	if services.PaymentJournal == nil {
+		return errors.New("payment journal is not set up")
+	}
+	return services.PaymentJournal.Add(payment)
+}
+
+func removeJournaledPayment(chargeID string) {
+	if err := services.PaymentJournal.Remove(chargeID); err != nil {
+		// The payment is credited again from the journal, the charge id makes it a no-op.
+		utils.Log("error removing payment %s from the journal: %v", chargeID, err)
+	}
+}
+
+// formatLostPayment has all the fields needed to credit the payment by hand.
+func formatLostPayment(payment objects.Payment) string {
+	return fmt.Sprintf("charge_id=%s provider_charge_id=%s user_id=%d package_id=%s quota=%d amount=%d currency=%s created_at=%s",
+		payment.ChargeID, payment.ProviderChargeID, payment.UserID, payment.PackageID, payment.Quota,
+		payment.Amount, payment.Currency, payment.CreatedAt.Format(time.RFC3339))
+}
+
+func sendPaymentThanks(bot *tgbotapi.BotAPI, profile *objects.Profile, payment objects.Payment) {
+	SendText(bot, profile.ChatID, i18n.T(profile.Language, "payment.thanks", i18n.Plural(profile.Language, "predictions", payment.Quota)))
+	sendPaymentMessage(bot, profile)
+}
+
+// CreditPendingPayments credits the payments which failed to be credited when they were made
+// and tells the users about it. It is run periodically.
+func CreditPendingPayments(ctx context.Context, bot *tgbotapi.BotAPI) {
+	var journaled []objects.Payment
+	if services.PaymentJournal != nil {
+		var err error
+		journaled, err = services.PaymentJournal.List()
+		if err != nil {
+			utils.Log("error reading payment journal: %v", err)
+		}
+	}
+	pending, err := services.Store.PendingPayments(ctx, pendingPaymentsPageSize)
+	if err != nil {
+		utils.Log("error listing pending payments: %v", err)
+	}
+	var done []string
+	for i, payment := range append(journaled, pending...) {
+		if ctx.Err() != nil {
+			break
+		}
+		credited, err := services.Store.CreditPayment(ctx, payment)
+		if err != nil {
+			utils.Log("error crediting pending payment %s of user %d: %v", payment.ChargeID, payment.UserID, err)
+			continue
+		}
+		if i < len(journaled) {
+			done = append(done, payment.ChargeID)
+		}
+		if !credited {
+			continue
+		}
+		utils.Log("pending payment %s of user %d: credited %d predictions", payment.ChargeID, payment.UserID, payment.Quota)
+		profile, err := services.Store.GetProfile(ctx, payment.UserID)
+		if err != nil {
+			utils.Log("error getting profile %d for pending payment %s: %v", payment.UserID, payment.ChargeID, err)
+			continue
+		}
+		sendPaymentThanks(bot, profile, payment)
+	}
+	if len(done) > 0 {
+		if err := services.PaymentJournal.Remove(done...); err != nil {
+			utils.Log("error removing credited payments from the journal: %v", err)
+		}
+	}
+}
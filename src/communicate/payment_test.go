@@ -0,0 +1,85 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// downStore fails the payments while down is set.
+type downStore struct {
+	database.Store
+	down bool
+}
+
+var errStoreDown = errors.New("store is down")
+
+func (s *downStore) RecordPayment(ctx context.Context, payment objects.Payment) error {
+	if s.down {
+		return errStoreDown
+	}
+	return s.Store.RecordPayment(ctx, payment)
+}
+
+func (s *downStore) CreditPayment(ctx context.Context, payment objects.Payment) (bool, error) {
+	if s.down {
+		return false, errStoreDown
+	}
+	return s.Store.CreditPayment(ctx, payment)
+}
+
+func TestSuccessfulPaymentWhileStoreIsDown(t *testing.T) {
+	f := &fakeTelegram{}
+	bot := newTestBot(t, f)
+	store := &downStore{Store: services.Store, down: true}
+	path := t.TempDir() + "/payments.jsonl"
+	services.Store = store
+	services.PaymentJournal = database.NewPaymentJournal(path)
+	ctx := context.Background()
+	profile := objects.NewProfile(1, "user", 1)
+	profile.Language = i18n.Default
+	if err := store.SaveProfile(ctx, &profile); err != nil {
+		t.Fatal(err)
+	}
+
+	message := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}, SuccessfulPayment: &tgbotapi.SuccessfulPayment{
+		Currency:                objects.StarsCurrency,
+		TotalAmount:             200,
+		InvoicePayload:          objects.QuotaPackage{ID: "medium", Quota: 5, Stars: 200}.InvoicePayload(),
+		TelegramPaymentChargeID: "charge",
+	}}
+	HandleSuccessfulPayment(bot, message, &profile)
+	calls := f.recorded()
+	if len(calls) != 1 || calls[0].text != i18n.T(profile.Language, "payment.pending") {
+		t.Fatalf("calls %+v", calls)
+	}
+
+	// The journal is read again after the restart, when the store is back.
+	store.down = false
+	services.PaymentJournal = database.NewPaymentJournal(path)
+	for range 2 {
+		CreditPendingPayments(ctx, bot)
+	}
+	if quota, err := store.GetQuota(ctx, 1); err != nil || quota.Available != 5 {
+		t.Errorf("quota %+v, %v", quota, err)
+	}
+	if payments, err := services.PaymentJournal.List(); err != nil || len(payments) != 0 {
+		t.Errorf("journal %+v, %v", payments, err)
+	}
+	thanks := 0
+	want := i18n.T(profile.Language, "payment.thanks", i18n.Plural(profile.Language, "predictions", 5))
+	for _, c := range f.recorded() {
+		if c.text == want {
+			thanks++
+		}
+	}
+	if thanks != 1 {
+		t.Errorf("thanks sent %d times: %+v", thanks, f.recorded())
+	}
+}
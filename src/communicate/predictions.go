@@ -0,0 +1,144 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/ai"
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/numerology"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/predictions"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// predictionTimeout limits the generation of an answer, so a stalled stream does not hold
+// the worker of the dispatcher and the shutdown.
+const predictionTimeout = 3 * time.Minute
+
+func predictionsKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, t := range predictions.Catalog {
+		title := fmt.Sprintf("%s (%d)", t.Title(lang), t.Cost)
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(title, "predict:"+t.ID),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// HandlePredictions shows the menu of prediction types.
+func HandlePredictions(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, i18n.T(profile.Language, "predictions.menu"))
+	msg.ReplyMarkup = predictionsKeyboard(profile.Language)
+	SendMessage(bot, &msg)
+}
+
+// HandlePredictCallback starts the chosen prediction or asks for its input first.
+func HandlePredictCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	chatID := callbackQuery.Message.Chat.ID
+	t, ok := predictions.Get(strings.TrimPrefix(callbackQuery.Data, "predict:"))
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "predictions.unavailable")))
+		return
+	}
+	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+	if t.NeedsPartner {
+		sendPartnersMenu(bot, chatID, profile)
+		return
+	}
+	if t.InputPrompt == "" {
+		runPrediction(bot, chatID, profile, t, "", nil)
+		return
+	}
+
+	startDialog(bot, chatID, profile, statePredictionInput, t.ID)
+}
+
+// runPrediction reserves the quota, streams the answer and saves it to the history.
+// The quota is refunded when the answer could not be delivered.
+func runPrediction(bot *tgbotapi.BotAPI, chatID int64, profile *objects.Profile, t predictions.Type, extra string, partner *objects.Partner) {
+	ctx := context.Background()
+	chart, err := numerology.Calculate(profile)
+	if err != nil {
+		utils.Log("Err calculating numerology chart: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrFillRequired))
+		return
+	}
+	input := predictions.Input{
+		Profile: profile,
+		Chart:   chart,
+		Now:     time.Now().In(profile.Location()),
+		Extra:   extra,
+		Partner: partner,
+	}
+	if partner != nil {
+		input.PartnerChart, err = numerology.CalculatePartner(*partner)
+		if err != nil {
+			utils.Log("Err calculating partner chart: %s", err.Error())
+			SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+			return
+		}
+	}
+	messages, err := t.Messages(input)
+	if errors.Is(err, predictions.ErrWrongInput) {
+		SendText(bot, chatID, i18n.T(profile.Language, "predictions.missing_data"))
+		return
+	}
+	if err != nil {
+		utils.Log("Err formatting profile: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrFillRequired))
+		return
+	}
+	utils.Log("Prediction %s messages: %v", t.ID, messages)
+
+	reservation, err := database.Reserve(ctx, services.Store, profile.UserID, t.Cost)
+	if errors.Is(err, database.ErrQuotaExhausted) {
+		SendText(bot, chatID, i18n.T(profile.Language, "predictions.no_quota", i18n.Plural(profile.Language, "predictions", t.Cost)))
+		return
+	}
+	if err != nil {
+		utils.Log("error on reserve quota: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+
+	aiCtx, cancel := context.WithTimeout(ctx, predictionTimeout)
+	defer cancel()
+	messageIDs, msgText, err := SendStream(bot, chatID, i18n.T(profile.Language, "predictions.waiting"), func(onDelta func(string)) (string, error) {
+		return services.Provider.StreamMessage(aiCtx, messages, onDelta)
+	})
+	if err != nil {
+		utils.Log("Err getting ai response: %s", err.Error())
+		if err := reservation.Refund(ctx); err != nil {
+			utils.Log("error on refund quota: %s", err.Error())
+		}
+		SendError(bot, chatID, aiError(profile.Language, err))
+		return
+	}
+	utils.Log("AI Answer: %s", msgText)
+	if err := reservation.Commit(ctx); err != nil {
+		utils.Log("error on commit quota: %s", err.Error())
+	}
+	savePrediction(profile, t.ID, msgText)
+	startConversation(profile, t.ID, messages, msgText, messageIDs)
+}
+
+// aiError is the error shown to the user when the model did not answer.
+func aiError(lang string, err error) error {
+	switch {
+	case errors.Is(err, ai.ErrContentFiltered):
+		return i18n.Error(lang, i18n.ErrContentFiltered)
+	case errors.Is(err, ai.ErrContextTooLong):
+		return i18n.Error(lang, i18n.ErrContextTooLong)
+	case errors.Is(err, ai.ErrAuth), errors.Is(err, ai.ErrQuotaExhausted):
+		utils.Log("ai provider account needs attention: %v", err)
+	}
+	return i18n.Error(lang, i18n.ErrGotSomeProblems)
+}
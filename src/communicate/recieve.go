@@ -1,101 +1,231 @@
 package communicate
 
 import (
+	"context"
 	"errors"
+	"strings"
 
 	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
 	"tgbot-numerologist/objects"
 	"tgbot-numerologist/utils"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func GetProfile(username string, chatID int64) (*objects.Profile, error) {
-	exists, err := database.UserExistsInRedis(username)
-	if err != nil {
-		utils.Log("error check exists in redis: %v", err)
-		return nil, errors.New(utils.ErrGotSomeProblems)
-	}
-	if exists {
-		profile, err := database.GetProfileFromRedis(username)
+func GetProfile(user *tgbotapi.User, chatID int64) (*objects.Profile, error) {
+	ctx := context.Background()
+
+	profile, err := services.Store.GetProfile(ctx, user.ID)
+	if err == nil {
+		if profile.Username == user.UserName && !profile.Inactive && profile.Language != "" {
+			return profile, nil
+		}
+		err := updateProfile(profile, func(p *objects.Profile) error {
+			if p.Username != user.UserName {
+				utils.Log("user %d changed username from %s to %s", user.ID, p.Username, user.UserName)
+				p.Username = user.UserName
+			}
+			if p.Inactive {
+				utils.Log("user %d is back", user.ID)
+				p.Inactive = false
+			}
+			if p.Language == "" {
+				p.Language = i18n.Detect(user.LanguageCode)
+			}
+			return nil
+		})
 		if err != nil {
-			utils.Log("error get profile from redis: %v", err)
-			return nil, errors.New(utils.ErrGotSomeProblems)
+			utils.Log("error save profile: %v", err)
+			return nil, err
 		}
 		return profile, nil
 	}
-	utils.Log("profile for user %s not exists, create with chat id %d", username, chatID)
-	profile := objects.NewProfile(username, chatID)
-	err = database.SaveProfileToRedis(&profile)
+	if !errors.Is(err, database.ErrNotFound) {
+		utils.Log("error get profile: %v", err)
+		return nil, err
+	}
+	utils.Log("profile for user %d (%s) not exists, create with chat id %d", user.ID, user.UserName, chatID)
+	newProfile := objects.NewProfile(user.ID, user.UserName, chatID)
+	newProfile.Language = i18n.Detect(user.LanguageCode)
+	err = services.Store.SaveProfile(ctx, &newProfile)
+	if err != nil {
+		utils.Log("error save profile: %v", err)
+		return nil, err
+	}
+	err = services.Store.InitQuota(ctx, user.ID, objects.DefaultQuota)
+	if err != nil {
+		utils.Log("error init quota: %v", err)
+		return nil, err
+	}
+	return &newProfile, nil
+}
+
+// updateProfile applies change to the stored profile and refreshes profile with the result.
+// Unlike SaveProfile it keeps the fields changed concurrently by the scheduler, the broadcasts and the admins,
+// so the handlers change only the fields they own. change may be called again when the profile is changed meanwhile.
+func updateProfile(profile *objects.Profile, change func(p *objects.Profile) error) error {
+	updated, err := services.Store.UpdateProfile(context.Background(), profile.UserID, change)
 	if err != nil {
-		utils.Log("error save to redis: %v", err)
-		return nil, errors.New(utils.ErrGotSomeProblems)
+		return err
+	}
+	*profile = *updated
+	return nil
+}
+
+// HandleUpdate routes a single update to the command, callback or edit handlers.
+func HandleUpdate(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	if update.PreCheckoutQuery != nil {
+		HandlePreCheckout(bot, update.PreCheckoutQuery)
+		return
+	}
+	var msg *tgbotapi.Message = nil
+	var profile *objects.Profile = nil
+	var err error
+	if update.CallbackQuery != nil {
+		msg = update.CallbackQuery.Message
+		profile, err = GetProfile(update.CallbackQuery.From, msg.Chat.ID)
+		if err != nil {
+			SendError(bot, msg.Chat.ID, i18n.Error(i18n.Detect(update.CallbackQuery.From.LanguageCode), i18n.ErrGotSomeProblems))
+			return
+		}
+		utils.Log("Get profile: %s:%s", profile.Username, profile.State)
+	}
+	if update.Message != nil {
+		msg = update.Message
+		LogMessage(bot, msg)
+		profile, err = GetProfile(msg.From, msg.Chat.ID)
+		if err != nil {
+			SendError(bot, msg.Chat.ID, i18n.Error(i18n.Detect(msg.From.LanguageCode), i18n.ErrGotSomeProblems))
+			return
+		}
+		utils.Log("Get profile: %s:%s", profile.Username, profile.State)
+	}
+	if msg == nil || profile == nil {
+		return
+	}
+	// The money is already taken, the payment is credited even when the user was banned after the checkout.
+	if msg.SuccessfulPayment != nil {
+		HandleSuccessfulPayment(bot, msg, profile)
+		return
+	}
+	if profile.Banned && !isAdmin(profile.UserID) {
+		utils.Log("ignore update of banned user %d", profile.UserID)
+		if update.CallbackQuery != nil {
+			bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
+		}
+		return
+	}
+	if update.CallbackQuery != nil {
+		DetermineCallback(bot, update.CallbackQuery, profile)
+		return
+	}
+
+	if msg.IsCommand() {
+		DetermineCommand(bot, msg, profile)
+		return
+	}
+
+	if profile.State != "" {
+		HandleDialogInput(bot, msg, profile)
+		return
 	}
-	return &profile, nil
+
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil && msg.ReplyToMessage.From.ID == bot.Self.ID {
+		HandleFollowUp(bot, msg, profile)
+		return
+	}
+
+	SendCommon(bot, msg, profile.Language)
 }
 
-func StartReceivingUpdates(bot *tgbotapi.BotAPI) {
+// StartReceivingUpdates long-polls Telegram and passes updates to the dispatcher until ctx is done.
+func StartReceivingUpdates(ctx context.Context, bot *tgbotapi.BotAPI, dispatcher *Dispatcher) {
 	utils.Log("Start Receiving Updates")
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
 	updates := bot.GetUpdatesChan(u)
+	// lastID is the last update passed to the dispatcher, -1 when there is none.
+	lastID := -1
 
-	for update := range updates {
-		var msg *tgbotapi.Message = nil
-		var profile *objects.Profile = nil
-		var err error
-		if update.CallbackQuery != nil {
-			msg = update.CallbackQuery.Message
-			profile, err = GetProfile(update.CallbackQuery.From.UserName, msg.Chat.ID)
-			utils.Log("Get profile: %s:%s", profile.Username, profile.EditingField)
-			if err != nil {
-				SendError(bot, msg.Chat.ID, errors.New(utils.ErrGotSomeProblems))
-				continue
+	for {
+		select {
+		case <-ctx.Done():
+			bot.StopReceivingUpdates()
+			n, drainedID := drainUpdates(context.WithoutCancel(ctx), updates, dispatcher)
+			lastID = max(lastID, drainedID)
+			if lastID >= 0 {
+				confirmUpdates(bot, lastID)
 			}
-		}
-		if update.Message != nil {
-			msg = update.Message
-			LogMessage(bot, msg)
-			profile, err = GetProfile(msg.From.UserName, msg.Chat.ID)
-			utils.Log("Get profile: %s:%s", profile.Username, profile.EditingField)
-			if err != nil {
-				SendError(bot, msg.Chat.ID, errors.New(utils.ErrGotSomeProblems))
+			utils.Log("Stop Receiving Updates, %d buffered updates are queued", n)
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err := dispatcher.Submit(ctx, update); err != nil {
+				utils.Log("error submitting update %d: %v", update.UpdateID, err)
 				continue
 			}
+			lastID = update.UpdateID
 		}
-		if msg == nil || profile == nil {
-			continue
-		}
-		if update.CallbackQuery != nil {
-			DetermineCallback(bot, update.CallbackQuery, profile)
-			continue
-		}
-
-		if msg.IsCommand() {
-			DetermineCommand(bot, msg, profile)
-			continue
-		}
+	}
+}
 
-		if profile.EditingField != "" {
-			HandleEditMessage(bot, msg, profile)
-			continue
+// drainUpdates passes the updates left in the buffer to the dispatcher and returns their number
+// and the id of the last one, -1 when there is none. The buffered updates may be not confirmed
+// to Telegram yet: the receiving goroutine confirms them with the next poll, which is not sent
+// while it waits for the free space in the buffer, so confirmUpdates must be called after.
+func drainUpdates(ctx context.Context, updates tgbotapi.UpdatesChannel, dispatcher *Dispatcher) (int, int) {
+	n, lastID := 0, -1
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return n, lastID
+			}
+			if err := dispatcher.Submit(ctx, update); err != nil {
+				// The next updates are not confirmed, Telegram delivers them again after the restart.
+				utils.Log("error submitting update %d: %v", update.UpdateID, err)
+				return n, lastID
+			}
+			n++
+			lastID = update.UpdateID
+		default:
+			return n, lastID
 		}
+	}
+}
 
-		SendCommon(bot, msg)
+// confirmUpdates tells Telegram that the updates up to lastID are received, so they are not
+// delivered again after the restart. The later updates, e.g. of the poll which was in flight
+// during the shutdown, stay unconfirmed and are delivered again.
+func confirmUpdates(bot *tgbotapi.BotAPI, lastID int) {
+	_, err := bot.GetUpdates(tgbotapi.UpdateConfig{Offset: lastID + 1, Limit: 1, Timeout: 0})
+	if err != nil {
+		utils.Log("error confirming updates up to %d: %v", lastID, err)
 	}
 }
 
 func DetermineCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	if strings.HasPrefix(message.Command(), adminCommandPrefix) {
+		HandleAdminCommand(bot, message, profile)
+		return
+	}
 	switch message.Command() {
 	case "start":
-		HandleIntro(bot, message)
+		if strings.HasPrefix(message.CommandArguments(), shareStartPrefix) {
+			HandleSharedStart(bot, message, profile)
+			return
+		}
+		HandleStart(bot, message, profile)
 	case "intro":
-		HandleIntro(bot, message)
+		HandleIntro(bot, message, profile)
 	case "help":
-		HandleHelp(bot, message)
+		HandleHelp(bot, message, profile)
 	case "feedback":
-		HandleFeedback(bot, message)
+		HandleFeedback(bot, message, profile)
 	case "payment":
 		HandlePayment(bot, message, profile)
 	case "reset":
@@ -104,50 +234,50 @@ func DetermineCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *
 		HandleProfile(bot, message, profile)
 	case "predictions":
 		HandlePredictions(bot, message, profile)
+	case "history":
+		HandleHistory(bot, message, profile)
+	case "share":
+		HandleShare(bot, message, profile)
+	case "subscribe":
+		HandleSubscribe(bot, message, profile)
+	case "unsubscribe":
+		HandleUnsubscribe(bot, message, profile)
+	case "language":
+		HandleLanguage(bot, message, profile)
 	case "stop":
 		HandleStop(bot, message, profile)
+	case "skip":
+		HandleSkip(bot, message, profile)
 	default:
-		SendText(bot, message.Chat.ID, utils.ErrUnknownCommand)
+		SendText(bot, message.Chat.ID, i18n.T(profile.Language, i18n.ErrUnknownCommand))
 	}
 }
 
 func DetermineCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
 	chatID := callbackQuery.Message.Chat.ID
-	switch callbackQuery.Data {
-	case "pay":
-		HandlePayButton(bot, callbackQuery, profile)
-		return
-	}
-	SendText(bot, chatID, "Напишите /stop для отмены ввода")
-	switch callbackQuery.Data {
-	case "edit_name":
-		SendText(bot, chatID, "Введите ваше имя:")
-	case "edit_surname":
-		SendText(bot, chatID, "Введите вашу фамилию:")
-	case "edit_birthdate":
-		SendText(bot, chatID, "Введите вашу дату рождения в формате dd.mm.yyyy:")
-	case "edit_bio":
-		SendText(bot, chatID, "Введите вашу биографию:")
-	case "edit_workplace":
-		SendText(bot, chatID, "Введите ваше место работы:")
-	case "edit_studyplace":
-		SendText(bot, chatID, "Введите ваше место учёбы:")
-	case "edit_hobby":
-		SendText(bot, chatID, "Опишите ваше хобби:")
-	}
-	profile.EditingField = callbackQuery.Data
-	utils.Log("enter edit phase: %s", profile.EditingField)
-
-	err := database.SaveProfileToRedis(profile)
-	if err != nil {
-		utils.Log("error on save profile when edit: %s", err.Error())
-		SendError(bot, callbackQuery.Message.Chat.ID, errors.New(utils.ErrGotSomeProblems))
-		return
+	switch {
+	case strings.HasPrefix(callbackQuery.Data, "buy:"):
+		HandleBuyButton(bot, callbackQuery, profile)
+	case callbackQuery.Data == "payments":
+		HandlePaymentsButton(bot, callbackQuery, profile)
+	case strings.HasPrefix(callbackQuery.Data, "history:"):
+		HandleHistoryCallback(bot, callbackQuery, profile)
+	case strings.HasPrefix(callbackQuery.Data, "predict:"):
+		HandlePredictCallback(bot, callbackQuery, profile)
+	case strings.HasPrefix(callbackQuery.Data, "partner:"):
+		HandlePartnerCallback(bot, callbackQuery, profile)
+	case strings.HasPrefix(callbackQuery.Data, "lang:"):
+		HandleLanguageCallback(bot, callbackQuery, profile)
+	case strings.HasPrefix(callbackQuery.Data, "sub:"):
+		HandleSubscriptionCallback(bot, callbackQuery, profile)
+	case strings.HasPrefix(callbackQuery.Data, adminCallbackPrefix):
+		HandleAdminCallback(bot, callbackQuery, profile)
+	case strings.HasPrefix(callbackQuery.Data, "cal:"):
+		HandleCalendarCallback(bot, callbackQuery, profile)
+	case strings.HasPrefix(callbackQuery.Data, objects.EditFieldPrefix):
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, i18n.T(profile.Language, "edit.waiting")))
+		startDialog(bot, chatID, profile, callbackQuery.Data, "")
+	default:
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
 	}
-	utils.Log("successfully saved data to redis")
-
-	p, err := database.GetProfileFromRedis(profile.Username)
-	utils.Log("get already written: %s:%s", p.Username, p.EditingField)
-
-	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, "Ожидаю ввода..."))
 }
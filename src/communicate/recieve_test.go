@@ -0,0 +1,63 @@
+package communicate
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestDrainUpdates(t *testing.T) {
+	tests := []struct {
+		name     string
+		buffered int
+		closed   bool
+	}{
+		{"empty", 0, false},
+		{"buffered", 5, false},
+		{"buffered and closed", 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled atomic.Int32
+			dispatcher := NewDispatcher(2, 2, func(update tgbotapi.Update) {
+				time.Sleep(time.Millisecond)
+				handled.Add(1)
+			})
+			updates := make(chan tgbotapi.Update, 10)
+			for i := range tt.buffered {
+				updates <- tgbotapi.Update{UpdateID: i, Message: &tgbotapi.Message{From: &tgbotapi.User{ID: int64(i % 2)}}}
+			}
+			if tt.closed {
+				close(updates)
+			}
+
+			// The queue of the dispatcher is smaller than the buffer, draining waits for the workers.
+			if n, lastID := drainUpdates(context.Background(), updates, dispatcher); n != tt.buffered || lastID != tt.buffered-1 {
+				t.Errorf("drained %d updates up to %d, want %d", n, lastID, tt.buffered)
+			}
+			if err := dispatcher.Stop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if int(handled.Load()) != tt.buffered {
+				t.Errorf("handled %d updates, want %d", handled.Load(), tt.buffered)
+			}
+		})
+	}
+}
+
+func TestConfirmUpdates(t *testing.T) {
+	f := &fakeTelegram{}
+	bot := newTestBot(t, f)
+	confirmUpdates(bot, 41)
+	calls := f.recorded()
+	if len(calls) != 1 || calls[0].method != "getUpdates" {
+		t.Fatalf("calls %+v", calls)
+	}
+	// The offset confirms the updates up to 41, the poll does not wait for the new ones.
+	if form := calls[0].form; form.Get("offset") != "42" || form.Get("limit") != "1" || form.Get("timeout") != "" && form.Get("timeout") != "0" {
+		t.Errorf("getUpdates with %v", form)
+	}
+}
@@ -1,58 +1,208 @@
 package communicate
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/markdown"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/ratelimit"
 	"tgbot-numerologist/utils"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func LogMessage(bot *tgbotapi.BotAPI, req *tgbotapi.Message) {
-	utils.Log("[%s] %s", req.From.UserName, req.Text)
+// SendConfig sets up the pipeline of the messages sent to the users.
+type SendConfig struct {
+	// GlobalRate is the number of messages per second to all the chats, Telegram allows about 30.
+	GlobalRate float64
+	// ChatRate is the number of messages per second to a chat, ChatBurst messages may be sent at once.
+	ChatRate  float64
+	ChatBurst int
+	// MaxRetries limits the retries after network errors and "Too Many Requests".
+	MaxRetries int
+	// Backoff is the delay before the first retry after a network error, doubled for every next one.
+	Backoff time.Duration
+	// MaxRetryAfter is the longest delay asked by Telegram the message waits for, it is dropped otherwise.
+	MaxRetryAfter time.Duration
 }
 
-func SendCommon(bot *tgbotapi.BotAPI, req *tgbotapi.Message) bool {
-	msgConf := tgbotapi.NewMessage(req.Chat.ID, utils.HelpMessage)
-	msg, err := bot.Send(msgConf)
-	if err != nil {
-		utils.Log("Error sending message: %v", err)
-		return false
-	} else {
-		LogMessage(bot, &msg)
-		return true
+// sendPipeline keeps the rate limits shared by all the sends.
+type sendPipeline struct {
+	config SendConfig
+	global *ratelimit.Limiter
+	chats  *ratelimit.Keyed
+}
+
+var pipeline = newSendPipeline(SendConfig{})
+
+func newSendPipeline(config SendConfig) *sendPipeline {
+	if config.GlobalRate <= 0 {
+		config.GlobalRate = 30
+	}
+	if config.ChatRate <= 0 {
+		config.ChatRate = 1
+	}
+	config.ChatBurst = max(config.ChatBurst, 1)
+	if config.Backoff <= 0 {
+		config.Backoff = time.Second
+	}
+	if config.MaxRetryAfter <= 0 {
+		config.MaxRetryAfter = time.Minute
+	}
+	return &sendPipeline{
+		config: config,
+		global: ratelimit.New(config.GlobalRate, 1),
+		chats:  ratelimit.NewKeyed(config.ChatRate, config.ChatBurst),
 	}
 }
 
-func SendText(bot *tgbotapi.BotAPI, chatId int64, text string) bool {
-	msgConf := tgbotapi.NewMessage(chatId, text)
-	msg, err := bot.Send(msgConf)
-	if err != nil {
-		utils.Log("Error sending message: %v", err)
-		return false
-	} else {
+// deliver sends the request to the chat keeping the rate limits. It waits for the delay asked by Telegram
+// after "Too Many Requests" and retries network and server errors with a backoff.
+// Returns the number of the attempts made.
+func deliver(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, c tgbotapi.Chattable) (tgbotapi.Message, int, error) {
+	p := pipeline
+	backoff := p.config.Backoff
+	for attempt := 1; ; attempt++ {
+		if err := p.global.Wait(ctx); err != nil {
+			return tgbotapi.Message{}, attempt - 1, err
+		}
+		if err := p.chats.Wait(ctx, chatID); err != nil {
+			return tgbotapi.Message{}, attempt - 1, err
+		}
+		msg, err := bot.Send(c)
+		if err == nil || attempt > p.config.MaxRetries {
+			return msg, attempt, err
+		}
+
+		var delay time.Duration
+		var tgErr *tgbotapi.Error
+		switch {
+		case errors.As(err, &tgErr) && tgErr.RetryAfter > 0:
+			delay = time.Duration(tgErr.RetryAfter) * time.Second
+			if delay > p.config.MaxRetryAfter {
+				return msg, attempt, err
+			}
+		case errors.As(err, &tgErr) && tgErr.Code < 500:
+			// Wrong requests, blocked bots and the like fail the same way every time.
+			return msg, attempt, err
+		default:
+			delay = backoff
+			backoff *= 2
+		}
+		utils.Log("error sending to chat %d, attempt %d, retry in %s: %v", chatID, attempt, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return msg, attempt, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isParseError reports whether Telegram could not parse the Markdown, the callers resend such messages as plain text.
+func isParseError(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && strings.Contains(tgErr.Message, "can't parse entities")
+}
+
+// isNotModified reports whether the edit did not change the message, it is not a delivery failure.
+func isNotModified(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && strings.Contains(tgErr.Message, "message is not modified")
+}
+
+// sendChattable delivers the request to the user. The user who blocked the bot is marked inactive
+// and an undelivered message is recorded to the dead letters.
+func sendChattable(bot *tgbotapi.BotAPI, chatID int64, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	msg, attempts, err := deliver(context.Background(), bot, chatID, c)
+	if err == nil {
 		LogMessage(bot, &msg)
-		return true
+		return msg, nil
+	}
+	utils.Log("Error sending message: %v", err)
+	if isParseError(err) || isNotModified(err) {
+		return msg, err
 	}
+	if isBotBlocked(err) {
+		// The bot works in private chats only, their ids are the ids of the users.
+		markInactive(chatID)
+	}
+	addDeadLetter(chatID, c, attempts, err)
+	return msg, err
+}
+
+// editChattable edits the message of the user like sendChattable, an edit which did not change the message succeeds.
+func editChattable(bot *tgbotapi.BotAPI, chatID int64, c tgbotapi.Chattable) error {
+	_, err := sendChattable(bot, chatID, c)
+	if isNotModified(err) {
+		return nil
+	}
+	return err
+}
+
+func addDeadLetter(chatID int64, c tgbotapi.Chattable, attempts int, err error) {
+	letter := objects.DeadLetter{CreatedAt: time.Now(), ChatID: chatID, Method: fmt.Sprintf("%T", c), Error: err.Error(), Attempts: attempts}
+	switch c := c.(type) {
+	case tgbotapi.MessageConfig:
+		letter.Method, letter.Text, letter.ParseMode = "sendMessage", c.Text, c.ParseMode
+	case *tgbotapi.MessageConfig:
+		letter.Method, letter.Text, letter.ParseMode = "sendMessage", c.Text, c.ParseMode
+	case tgbotapi.EditMessageTextConfig:
+		letter.Method, letter.Text, letter.ParseMode = "editMessageText", c.Text, c.ParseMode
+	case tgbotapi.PhotoConfig:
+		letter.Method, letter.Text, letter.ParseMode = "sendPhoto", c.Caption, c.ParseMode
+	}
+	if err := services.Store.AddDeadLetter(context.Background(), letter); err != nil {
+		utils.Log("error adding dead letter for chat %d: %v", chatID, err)
+	}
+}
+
+func LogMessage(bot *tgbotapi.BotAPI, req *tgbotapi.Message) {
+	utils.Log("[%s] %s", req.From.UserName, req.Text)
+}
+
+func SendCommon(bot *tgbotapi.BotAPI, req *tgbotapi.Message, lang string) bool {
+	return SendText(bot, req.Chat.ID, i18n.T(lang, "help"))
+}
+
+func SendText(bot *tgbotapi.BotAPI, chatId int64, text string) bool {
+	_, err := sendChattable(bot, chatId, tgbotapi.NewMessage(chatId, text))
+	return err == nil
 }
 
 func SendMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.MessageConfig) bool {
-	m, err := bot.Send(msg)
-	if err != nil {
-		utils.Log("Error sending message: %v", err)
-		return false
-	} else {
-		LogMessage(bot, &m)
-		return true
+	_, err := sendChattable(bot, msg.ChatID, msg)
+	return err == nil
+}
+
+// sendMarkdownPart sends a part of the Markdown text which fits into a message rendered as HTML,
+// the plain text is sent when Telegram rejects the markup.
+func sendMarkdownPart(bot *tgbotapi.BotAPI, chatID int64, text string) (tgbotapi.Message, error) {
+	msg := tgbotapi.NewMessage(chatID, markdown.ToHTML(text))
+	msg.ParseMode = tgbotapi.ModeHTML
+	m, err := sendChattable(bot, chatID, msg)
+	if isParseError(err) {
+		m, err = sendChattable(bot, chatID, tgbotapi.NewMessage(chatID, text))
 	}
+	return m, err
 }
 
-func SendError(bot *tgbotapi.BotAPI, chatId int64, err error) bool {
-	msgConf := tgbotapi.NewMessage(chatId, err.Error())
-	msg, err := bot.Send(msgConf)
-	if err != nil {
-		utils.Log("Error sending message: %v", err)
-		return false
-	} else {
-		LogMessage(bot, &msg)
-		return true
+// SendMarkdown sends the Markdown written by the model, a long text is split into several messages.
+func SendMarkdown(bot *tgbotapi.BotAPI, chatID int64, text string) bool {
+	for _, part := range markdown.Split(text, maxMessageLength) {
+		if _, err := sendMarkdownPart(bot, chatID, part); err != nil {
+			return false
+		}
 	}
+	return true
+}
+
+func SendError(bot *tgbotapi.BotAPI, chatId int64, err error) bool {
+	return SendText(bot, chatId, err.Error())
 }
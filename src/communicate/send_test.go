@@ -0,0 +1,39 @@
+package communicate
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestEditChattable(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		err         bool
+		deadLetters int
+	}{
+		{"edited", "", false, 0},
+		{"not modified", "Bad Request: message is not modified", false, 0},
+		{"failed", "Bad Request: message to edit not found", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeTelegram{reject: func(c call) string { return tt.description }}
+			bot := newTestBot(t, f)
+			err := editChattable(bot, 1, tgbotapi.NewEditMessageText(1, 5, "text"))
+			if (err != nil) != tt.err {
+				t.Errorf("error = %v", err)
+			}
+			letters, err := services.Store.ListDeadLetters(context.Background(), 10)
+			if err != nil || len(letters) != tt.deadLetters {
+				t.Errorf("dead letters %+v, %v", letters, err)
+			}
+			// The edit goes through the send pipeline.
+			if calls := f.recorded(); len(calls) != 1 || calls[0].method != "editMessageText" {
+				t.Errorf("calls %+v", calls)
+			}
+		})
+	}
+}
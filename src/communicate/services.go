@@ -0,0 +1,42 @@
+package communicate
+
+import (
+	"time"
+
+	"tgbot-numerologist/ai"
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/objects"
+)
+
+// Services holds the external dependencies used by the handlers.
+type Services struct {
+	Provider ai.Provider
+	Store    database.Store
+	// Packages are quota packages available for purchase.
+	Packages []objects.QuotaPackage
+	// PaymentJournal keeps the payments while the store is not available.
+	PaymentJournal *database.PaymentJournal
+	FollowUp       FollowUpConfig
+	Broadcast      BroadcastConfig
+	Send           SendConfig
+	Forecast       ForecastConfig
+	// Admins are the Telegram user ids allowed to run the admin commands.
+	Admins []int64
+}
+
+// FollowUpConfig sets up the follow-up questions about predictions.
+type FollowUpConfig struct {
+	// Cost is the quota taken for a question in objects.QuotaUnit parts, e.g. a quarter of a prediction.
+	Cost int64
+	// TokenBudget limits the conversation sent to the model, the oldest questions are dropped.
+	TokenBudget int
+	// TTL is how long the user can ask questions after the last answer.
+	TTL time.Duration
+}
+
+var services Services
+
+func Init(s Services) {
+	services = s
+	pipeline = newSendPipeline(s.Send)
+}
@@ -0,0 +1,129 @@
+package communicate
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"tgbot-numerologist/markdown"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Telegram allows about one edit per second in a chat, keep some margin.
+	streamEditInterval = 1500 * time.Millisecond
+	maxMessageLength   = 4096
+)
+
+// StreamFunc runs the generation and calls onDelta for every received piece of text.
+type StreamFunc func(onDelta func(delta string)) (string, error)
+
+// truncateText cuts text to the Telegram message limit.
+func truncateText(text string) string {
+	if utf8.RuneCountInString(text) <= maxMessageLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxMessageLength-1]) + "…"
+}
+
+func editText(bot *tgbotapi.BotAPI, chatID int64, messageID int, text, parseMode string) error {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = parseMode
+	_, _, err := deliver(context.Background(), bot, chatID, edit)
+	if isNotModified(err) {
+		// The last intermediate edit already shows the same text.
+		return nil
+	}
+	return err
+}
+
+// editMarkdown replaces the text of the message with the Markdown rendered as HTML,
+// the plain text is used when Telegram rejects the markup.
+func editMarkdown(bot *tgbotapi.BotAPI, chatID int64, messageID int, text string) error {
+	err := editText(bot, chatID, messageID, markdown.ToHTML(text), tgbotapi.ModeHTML)
+	if err == nil {
+		return nil
+	}
+	utils.Log("Error editing message with HTML, fallback to plain text: %v", err)
+	if err := editText(bot, chatID, messageID, text, ""); err != nil {
+		utils.Log("Error editing message: %v", err)
+		addDeadLetter(chatID, tgbotapi.NewEditMessageText(chatID, messageID, text), pipeline.config.MaxRetries+1, err)
+		return err
+	}
+	return nil
+}
+
+// SendStream sends the placeholder and edits it while the stream produces text.
+// Intermediate edits are plain text and throttled, Markdown is rendered only in the final text.
+// A long text replaces the placeholder with its first part and the rest is sent in the next messages.
+// Returns the ids of the messages and the whole generated text, the error is set when generation or delivery failed.
+func SendStream(bot *tgbotapi.BotAPI, chatID int64, placeholder string, stream StreamFunc) ([]int, string, error) {
+	m, err := sendChattable(bot, chatID, tgbotapi.NewMessage(chatID, placeholder))
+	if err != nil {
+		return nil, "", err
+	}
+	messageIDs := []int{m.MessageID}
+
+	var mu sync.Mutex
+	var text string
+	changed := false
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(streamEditInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			mu.Lock()
+			current := text
+			needEdit := changed && current != ""
+			changed = false
+			mu.Unlock()
+			if !needEdit {
+				continue
+			}
+			if err := editText(bot, chatID, m.MessageID, truncateText(current), ""); err != nil {
+				utils.Log("Error editing streamed message: %v", err)
+			}
+		}
+	}()
+
+	result, err := stream(func(delta string) {
+		mu.Lock()
+		text += delta
+		changed = true
+		mu.Unlock()
+	})
+	close(done)
+	wg.Wait()
+	if err != nil {
+		return messageIDs, result, err
+	}
+
+	parts := markdown.Split(result, maxMessageLength)
+	if len(parts) == 0 {
+		return messageIDs, result, nil
+	}
+	if err := editMarkdown(bot, chatID, m.MessageID, parts[0]); err != nil {
+		return messageIDs, result, err
+	}
+	for _, part := range parts[1:] {
+		m, err := sendMarkdownPart(bot, chatID, part)
+		if err != nil {
+			return messageIDs, result, err
+		}
+		messageIDs = append(messageIDs, m.MessageID)
+	}
+	return messageIDs, result, nil
+}
@@ -0,0 +1,233 @@
+package communicate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestMain(m *testing.M) {
+	utils.Logger.SetOutput(io.Discard)
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// call is a request received by the fake Telegram API.
+type call struct {
+	method    string
+	messageID string
+	text      string
+	parseMode string
+	// form keeps all the parameters of the request.
+	form url.Values
+}
+
+// fakeTelegram answers the Bot API methods and records the calls. reject returns the error
+// description for the calls that fail.
+type fakeTelegram struct {
+	mu     sync.Mutex
+	calls  []call
+	nextID int
+	reject func(c call) string
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	c := call{method: method, messageID: r.Form.Get("message_id"), text: r.Form.Get("text"), parseMode: r.Form.Get("parse_mode"), form: r.Form}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if method == "getMe" {
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"id": 100, "is_bot": true, "first_name": "bot", "username": "bot"}})
+		return
+	}
+	f.calls = append(f.calls, c)
+	if f.reject != nil {
+		if description := f.reject(c); description != "" {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 400, "description": description})
+			return
+		}
+	}
+	if method == "getUpdates" {
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": []any{}})
+		return
+	}
+	f.nextID++
+	result := map[string]any{
+		"message_id": f.nextID,
+		"date":       0,
+		"chat":       map[string]any{"id": 1, "type": "private"},
+		"from":       map[string]any{"id": 100, "is_bot": true, "first_name": "bot", "username": "bot"},
+		"text":       c.text,
+	}
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+func (f *fakeTelegram) recorded() []call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]call(nil), f.calls...)
+}
+
+// newTestBot starts the fake Telegram API and sets up the services with the memory store.
+func newTestBot(t *testing.T, f *fakeTelegram) *tgbotapi.BotAPI {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	Init(Services{
+		Store: database.NewMemoryStore(),
+		Send:  SendConfig{GlobalRate: 1000, ChatRate: 1000, ChatBurst: 100, Backoff: time.Millisecond},
+	})
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", srv.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bot
+}
+
+// words streams the text word by word.
+func words(text string, err error) StreamFunc {
+	return func(onDelta func(string)) (string, error) {
+		for _, word := range strings.SplitAfter(text, " ") {
+			onDelta(word)
+		}
+		return text, err
+	}
+}
+
+func TestSendStream(t *testing.T) {
+	long := strings.Repeat(strings.Repeat("слово ", 100)+"\n\n", 15)
+	failed := errors.New("generation failed")
+	tests := []struct {
+		name    string
+		stream  StreamFunc
+		reject  func(c call) string
+		err     error
+		methods []string
+		last    call
+	}{
+		{
+			name:    "short",
+			stream:  words("Число **7**", nil),
+			methods: []string{"sendMessage", "editMessageText"},
+			last:    call{method: "editMessageText", messageID: "1", text: "Число <b>7</b>", parseMode: "HTML"},
+		},
+		{
+			name:    "long",
+			stream:  words(long, nil),
+			methods: []string{"sendMessage", "editMessageText", "sendMessage", "sendMessage"},
+			last:    call{method: "sendMessage", parseMode: "HTML"},
+		},
+		{
+			name:    "generation failed",
+			stream:  words("Число", failed),
+			err:     failed,
+			methods: []string{"sendMessage"},
+			last:    call{method: "sendMessage", text: "…"},
+		},
+		{
+			name:   "markup rejected",
+			stream: words("Число **7**", nil),
+			reject: func(c call) string {
+				if c.parseMode == "HTML" {
+					return "Bad Request: can't parse entities"
+				}
+				return ""
+			},
+			methods: []string{"sendMessage", "editMessageText", "editMessageText"},
+			last:    call{method: "editMessageText", messageID: "1", text: "Число **7**"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeTelegram{reject: tt.reject}
+			bot := newTestBot(t, f)
+			ids, text, err := SendStream(bot, 1, "…", tt.stream)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			calls := f.recorded()
+			var methods []string
+			sent := 0
+			for _, c := range calls {
+				methods = append(methods, c.method)
+				if c.method == "sendMessage" {
+					sent++
+				}
+			}
+			if fmt.Sprint(methods) != fmt.Sprint(tt.methods) {
+				t.Fatalf("calls %v, want %v", methods, tt.methods)
+			}
+			last := calls[len(calls)-1]
+			if last.method != tt.last.method || last.parseMode != tt.last.parseMode ||
+				tt.last.messageID != "" && last.messageID != tt.last.messageID ||
+				tt.last.text != "" && last.text != tt.last.text {
+				t.Errorf("last call %+v, want %+v", last, tt.last)
+			}
+			// The placeholder and every next part are the messages of the answer.
+			if len(ids) != sent {
+				t.Errorf("message ids %v for calls %v", ids, methods)
+			}
+			if tt.err == nil && text == "" {
+				t.Error("the generated text is not returned")
+			}
+		})
+	}
+}
+
+func TestSendStreamEditsWhileGenerating(t *testing.T) {
+	f := &fakeTelegram{}
+	bot := newTestBot(t, f)
+	_, _, err := SendStream(bot, 1, "…", func(onDelta func(string)) (string, error) {
+		onDelta("Число ")
+		time.Sleep(streamEditInterval + 300*time.Millisecond)
+		onDelta("*7*")
+		return "Число *7*", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := f.recorded()
+	if len(calls) != 3 {
+		t.Fatalf("calls %+v", calls)
+	}
+	// The intermediate edit is plain text, the final one is rendered.
+	if calls[1].text != "Число " || calls[1].parseMode != "" {
+		t.Errorf("intermediate edit %+v", calls[1])
+	}
+	if calls[2].text != "Число <i>7</i>" || calls[2].parseMode != "HTML" {
+		t.Errorf("final edit %+v", calls[2])
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"short", 5},
+		{strings.Repeat("я", maxMessageLength), maxMessageLength},
+		{strings.Repeat("я", maxMessageLength+10), maxMessageLength},
+	}
+	for _, tt := range tests {
+		got := truncateText(tt.in)
+		if n := len([]rune(got)); n != tt.want {
+			t.Errorf("truncateText of %d runes has %d", len([]rune(tt.in)), n)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tgbot-numerologist/database"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/numerology"
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/predictions"
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	subscriptionType     = "daily"
+	subscriptionPageSize = 100
+	forecastTimeout      = 2 * time.Minute
+	// maxForecastAttempts is the number of the runs trying to deliver the forecast of a day, then it is skipped.
+	maxForecastAttempts = 3
+)
+
+var subscriptionTimes = []string{"07:00", "08:00", "09:00", "10:00", "12:00", "18:00", "20:00", "22:00"}
+
+// subscriptionTimezones are offered as buttons, their titles are the "timezone.<id>" messages.
+var subscriptionTimezones = []string{
+	"Europe/Kaliningrad", "Europe/Moscow", "Europe/Samara", "Asia/Yekaterinburg", "Asia/Omsk",
+	"Asia/Novosibirsk", "Asia/Krasnoyarsk", "Asia/Irkutsk", "Asia/Yakutsk", "Asia/Vladivostok",
+	"Asia/Magadan", "Asia/Kamchatka", "Europe/Minsk", "Asia/Almaty", "Europe/London",
+	"Europe/Berlin", "America/New_York", "UTC",
+}
+
+var errForecastNotDue = errors.New("forecast is not due")
+
+func subscriptionTimesKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, t := range subscriptionTimes {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(t, "sub:time:"+t))
+		if len(row) == 4 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+func subscriptionTimezonesKeyboard(lang, t string) tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, tz := range subscriptionTimezones {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "timezone."+tz), "sub:set:"+t+":"+tz))
+		if len(row) == 2 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+func parseSubscriptionTime(t string) (int, int, error) {
+	hourStr, minuteStr, ok := strings.Cut(t, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("wrong time %q", t)
+	}
+	hour, err := strconv.Atoi(hourStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	minute, err := strconv.Atoi(minuteStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return hour, minute, nil
+}
+
+// subscribe saves the subscription of the profile and confirms it to the user.
+func subscribe(bot *tgbotapi.BotAPI, chatID int64, profile *objects.Profile, t, timezone string) {
+	hour, minute, err := parseSubscriptionTime(t)
+	if err != nil {
+		SendText(bot, chatID, i18n.T(profile.Language, "subscribe.wrong_time"))
+		return
+	}
+	subscription, err := objects.NewSubscription(hour, minute, timezone, time.Now())
+	if err != nil {
+		SendText(bot, chatID, i18n.T(profile.Language, "subscribe.wrong_time"))
+		return
+	}
+	err = updateProfile(profile, func(p *objects.Profile) error {
+		p.Subscription = &subscription
+		return nil
+	})
+	if err != nil {
+		utils.Log("error on save profile when subscribe: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	forecast, _ := predictions.Get(subscriptionType)
+	SendText(bot, chatID, i18n.T(profile.Language, "subscribe.done",
+		subscription.Title(profile.Language), i18n.Plural(profile.Language, "predictions", forecast.Cost)))
+}
+
+// HandleSubscribe asks the time and the timezone of the daily forecast,
+// they can also be passed as arguments: /subscribe 09:30 Europe/Moscow.
+func HandleSubscribe(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	chatID := message.Chat.ID
+	if profile.Name == "" || profile.BirthDate.IsZero() {
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrFillRequired))
+		return
+	}
+	if args := strings.Fields(message.CommandArguments()); len(args) == 2 {
+		subscribe(bot, chatID, profile, args[0], args[1])
+		return
+	}
+
+	text := i18n.T(profile.Language, "subscribe.choose_time")
+	if profile.Subscription != nil {
+		text = i18n.T(profile.Language, "subscribe.current", profile.Subscription.Title(profile.Language)) + text
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = subscriptionTimesKeyboard()
+	SendMessage(bot, &msg)
+}
+
+func HandleUnsubscribe(bot *tgbotapi.BotAPI, message *tgbotapi.Message, profile *objects.Profile) {
+	chatID := message.Chat.ID
+	if profile.Subscription == nil {
+		SendText(bot, chatID, i18n.T(profile.Language, "subscribe.none"))
+		return
+	}
+	err := updateProfile(profile, func(p *objects.Profile) error {
+		p.Subscription = nil
+		return nil
+	})
+	if err != nil {
+		utils.Log("error on save profile when unsubscribe: %s", err.Error())
+		SendError(bot, chatID, i18n.Error(profile.Language, i18n.ErrGotSomeProblems))
+		return
+	}
+	SendText(bot, chatID, i18n.T(profile.Language, "subscribe.off"))
+}
+
+// HandleSubscriptionCallback handles sub:time:<HH:MM> and sub:set:<HH:MM>:<timezone> buttons.
+func HandleSubscriptionCallback(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery, profile *objects.Profile) {
+	chatID := callbackQuery.Message.Chat.ID
+	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+	action, value, _ := strings.Cut(strings.TrimPrefix(callbackQuery.Data, "sub:"), ":")
+	switch action {
+	case "time":
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, callbackQuery.Message.MessageID,
+			i18n.T(profile.Language, "subscribe.choose_timezone", value),
+			subscriptionTimezonesKeyboard(profile.Language, value))
+		editChattable(bot, chatID, edit)
+	case "set":
+		hour, rest, _ := strings.Cut(value, ":")
+		minute, timezone, _ := strings.Cut(rest, ":")
+		subscribe(bot, chatID, profile, hour+":"+minute, timezone)
+	}
+}
+
+// ForecastConfig sets up the delivery of the daily forecasts.
+type ForecastConfig struct {
+	// Workers is the number of the forecasts prepared at the same time.
+	Workers int
+}
+
+// SendDueForecasts delivers the daily forecast to every subscriber whose time has come.
+// It is run by the scheduler every minute, so the forecasts missed while the bot was down are sent after the restart.
+// The subscribers are taken from the index of the due forecasts in batches, a batch is delivered by the workers.
+func SendDueForecasts(ctx context.Context, bot *tgbotapi.BotAPI, now time.Time) {
+	// The users whose forecast failed stay due, they are tried again on the next runs up to maxForecastAttempts.
+	seen := make(map[int64]bool)
+	for ctx.Err() == nil {
+		userIDs, err := services.Store.DueForecasts(ctx, now, subscriptionPageSize)
+		if err != nil {
+			utils.Log("error listing due forecasts: %v", err)
+			return
+		}
+		userIDs = slices.DeleteFunc(userIDs, func(userID int64) bool {
+			return seen[userID]
+		})
+		if len(userIDs) == 0 {
+			return
+		}
+		queue := make(chan int64)
+		var wg sync.WaitGroup
+		for range min(max(services.Forecast.Workers, 1), len(userIDs)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for userID := range queue {
+					deliverForecast(ctx, bot, userID, now)
+				}
+			}()
+		}
+		for _, userID := range userIDs {
+			if ctx.Err() != nil {
+				break
+			}
+			seen[userID] = true
+			queue <- userID
+		}
+		close(queue)
+		wg.Wait()
+	}
+}
+
+// deliverForecast marks the forecast of the date as sent, so it is sent only once, and delivers it.
+// The mark is removed when the delivery fails, so the forecast is tried again on the next run,
+// unless it failed maxForecastAttempts times. The shutdown does not count as a failure.
+func deliverForecast(ctx context.Context, bot *tgbotapi.BotAPI, userID int64, now time.Time) {
+	var date, previous string
+	profile, err := services.Store.UpdateProfile(ctx, userID, func(profile *objects.Profile) error {
+		date = ""
+		if profile.ChatID == 0 {
+			// The profile is deleted.
+			return errForecastNotDue
+		}
+		if profile.Subscription == nil || profile.Banned || profile.Inactive {
+			// The index is out of date, saving the profile as it is updates it.
+			return nil
+		}
+		due, ok := profile.Subscription.Due(now)
+		if !ok {
+			return nil
+		}
+		date = due
+		previous = profile.Subscription.LastSent
+		profile.Subscription.LastSent = date
+		return nil
+	})
+	if errors.Is(err, errForecastNotDue) {
+		return
+	}
+	if err != nil {
+		utils.Log("error marking forecast of user %d: %v", userID, err)
+		return
+	}
+	if date == "" {
+		return
+	}
+
+	sendErr := sendForecast(ctx, bot, profile, now.In(profile.Subscription.Location()))
+	if sendErr == nil && profile.Subscription.Failures == 0 {
+		return
+	}
+	shutdown := sendErr != nil && ctx.Err() != nil
+	_, err = services.Store.UpdateProfile(context.Background(), userID, func(profile *objects.Profile) error {
+		if profile.Subscription == nil || profile.Subscription.LastSent != date {
+			return errForecastNotDue
+		}
+		switch {
+		case sendErr == nil:
+			profile.Subscription.Failures = 0
+		case shutdown:
+			profile.Subscription.LastSent = previous
+		case profile.Subscription.Failures+1 >= maxForecastAttempts:
+			// The forecast of the date is skipped, it stays marked as sent.
+			utils.Log("error sending forecast to user %d, skipped after %d attempts: %v", userID, maxForecastAttempts, sendErr)
+			profile.Subscription.Failures = 0
+		default:
+			utils.Log("error sending forecast to user %d, it is tried again: %v", userID, sendErr)
+			profile.Subscription.Failures++
+			profile.Subscription.LastSent = previous
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errForecastNotDue) {
+		utils.Log("error unmarking forecast of user %d: %v", userID, err)
+	}
+}
+
+func sendForecast(ctx context.Context, bot *tgbotapi.BotAPI, profile *objects.Profile, now time.Time) error {
+	t, ok := predictions.Get(subscriptionType)
+	if !ok {
+		return fmt.Errorf("unknown prediction type %s", subscriptionType)
+	}
+	chart, err := numerology.Calculate(profile)
+	if err != nil {
+		return err
+	}
+	messages, err := t.Messages(predictions.Input{Profile: profile, Chart: chart, Now: now})
+	if err != nil {
+		return err
+	}
+
+	reservation, err := database.Reserve(ctx, services.Store, profile.UserID, t.Cost)
+	if errors.Is(err, database.ErrQuotaExhausted) {
+		SendText(bot, profile.ChatID, i18n.T(profile.Language, "subscribe.no_quota"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	aiCtx, cancel := context.WithTimeout(ctx, forecastTimeout)
+	defer cancel()
+	text, err := services.Provider.SendMessage(aiCtx, messages)
+	// The model answers in Markdown, it is rendered and split like the other predictions.
+	if err == nil && !SendMarkdown(bot, profile.ChatID, i18n.T(profile.Language, "subscribe.forecast", now.Format("02.01.2006"), text)) {
+		err = errors.New("forecast message is not delivered")
+	}
+	if err != nil {
+		if err := reservation.Refund(context.Background()); err != nil {
+			utils.Log("error on refund quota: %s", err.Error())
+		}
+		return err
+	}
+	if err := reservation.Commit(context.Background()); err != nil {
+		utils.Log("error on commit quota: %s", err.Error())
+	}
+	savePrediction(profile, t.ID, text)
+	return nil
+}
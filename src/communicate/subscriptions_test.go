@@ -0,0 +1,87 @@
+package communicate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tgbot-numerologist/ai"
+	"tgbot-numerologist/objects"
+)
+
+// flakyProvider fails the first failures requests and answers the next ones.
+type flakyProvider struct {
+	ai.Provider
+	failures int
+	calls    int
+}
+
+func (p *flakyProvider) SendMessage(ctx context.Context, messages []ai.Message) (string, error) {
+	p.calls++
+	if p.calls <= p.failures {
+		return "", errors.New("provider is down")
+	}
+	return "Прогноз", nil
+}
+
+func TestSendDueForecastsRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		delivered bool
+		available int64
+	}{
+		{"delivered at once", 0, true, 4},
+		{"delivered on the next run", 1, true, 4},
+		{"delivered on the last attempt", maxForecastAttempts - 1, true, 4},
+		{"skipped after the attempts", maxForecastAttempts, false, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeTelegram{}
+			bot := newTestBot(t, f)
+			provider := &flakyProvider{Provider: ai.NewFakeProvider(), failures: tt.failures}
+			services.Provider = provider
+			ctx := context.Background()
+			profile := objects.NewProfile(1, "user", 1)
+			profile.Name = "Анна"
+			profile.BirthDate = time.Date(1990, 3, 5, 0, 0, 0, 0, time.UTC)
+			profile.Subscription = &objects.Subscription{Hour: 9, Timezone: "UTC"}
+			if err := services.Store.SaveProfile(ctx, &profile); err != nil {
+				t.Fatal(err)
+			}
+			if err := services.Store.InitQuota(ctx, 1, 5); err != nil {
+				t.Fatal(err)
+			}
+
+			now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+			for run := range maxForecastAttempts + 1 {
+				SendDueForecasts(ctx, bot, now.Add(time.Duration(run)*time.Minute))
+			}
+			if provider.calls != min(tt.failures+1, maxForecastAttempts) {
+				t.Errorf("the forecast is generated %d times", provider.calls)
+			}
+			sent := 0
+			for _, c := range f.recorded() {
+				if c.method == "sendMessage" {
+					sent++
+				}
+			}
+			if delivered := sent > 0; delivered != tt.delivered {
+				t.Errorf("delivered = %v, want %v", delivered, tt.delivered)
+			}
+			saved, err := services.Store.GetProfile(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The day is marked as sent in the end, the failures are reset for the next day.
+			if saved.Subscription.LastSent != "2026-10-18" || saved.Subscription.Failures != 0 {
+				t.Errorf("subscription %+v", saved.Subscription)
+			}
+			if quota, _ := services.Store.GetQuota(ctx, 1); quota.Available != tt.available {
+				t.Errorf("quota %+v, want %d available", quota, tt.available)
+			}
+		})
+	}
+}
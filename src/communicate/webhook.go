@@ -0,0 +1,118 @@
+package communicate
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"tgbot-numerologist/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	secretTokenHeader      = "X-Telegram-Bot-Api-Secret-Token"
+	webhookShutdownTimeout = 10 * time.Second
+)
+
+type WebhookConfig struct {
+	// URL is the public HTTPS address Telegram sends updates to.
+	URL string
+	// ListenAddr is the local address of the HTTP server, e.g. ":8080".
+	ListenAddr string
+	// Path is the HTTP path the updates are accepted on, e.g. "/telegram".
+	Path string
+	// SecretToken is sent by Telegram in every request and checked by the server.
+	SecretToken string
+}
+
+func setWebhook(bot *tgbotapi.BotAPI, config WebhookConfig) error {
+	params := tgbotapi.Params{}
+	params["url"] = config.URL
+	params.AddNonEmpty("secret_token", config.SecretToken)
+	_, err := bot.MakeRequest("setWebhook", params)
+	return err
+}
+
+func deleteWebhook(bot *tgbotapi.BotAPI) error {
+	_, err := bot.Request(tgbotapi.DeleteWebhookConfig{})
+	return err
+}
+
+func webhookHandler(config WebhookConfig, dispatcher *Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		token := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(config.SecretToken)) != 1 {
+			utils.Log("webhook request with wrong secret token from %s", r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var update tgbotapi.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			utils.Log("error decoding webhook update: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Telegram redelivers the update when the response is not successful.
+		if err := dispatcher.Submit(r.Context(), update); err != nil {
+			utils.Log("error submitting update %d: %v", update.UpdateID, err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// StartWebhook registers the webhook and serves incoming updates until ctx is done.
+// The webhook is deleted on exit, so the bot can be switched back to long polling.
+func StartWebhook(ctx context.Context, bot *tgbotapi.BotAPI, config WebhookConfig, dispatcher *Dispatcher) error {
+	if _, err := url.ParseRequestURI(config.URL); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, webhookHandler(config, dispatcher))
+	server := &http.Server{
+		Addr:    config.ListenAddr,
+		Handler: mux,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	if err := setWebhook(bot, config); err != nil {
+		server.Close()
+		return err
+	}
+	utils.Log("Start Receiving Updates with webhook %s on %s%s", config.URL, config.ListenAddr, config.Path)
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-serveErr:
+	}
+
+	utils.Log("Stop Receiving Updates")
+	if err := deleteWebhook(bot); err != nil {
+		utils.Log("error deleting webhook: %v", err)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		utils.Log("error shutting down webhook server: %v", err)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,17 @@
+package database
+
+import (
+	"context"
+
+	"tgbot-numerologist/objects"
+)
+
+// MaxAuditRecords is the number of the latest audit records kept, the older ones are dropped.
+const MaxAuditRecords = 10000
+
+// AuditStore keeps the log of admin commands, records are never changed.
+type AuditStore interface {
+	AddAuditRecord(ctx context.Context, record objects.AuditRecord) error
+	// ListAuditRecords returns up to limit latest records, the newest first.
+	ListAuditRecords(ctx context.Context, limit int) ([]objects.AuditRecord, error)
+}
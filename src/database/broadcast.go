@@ -0,0 +1,19 @@
+package database
+
+import (
+	"context"
+
+	"tgbot-numerologist/objects"
+)
+
+// BroadcastStore keeps the broadcasts with their progress.
+type BroadcastStore interface {
+	// SaveBroadcast creates or overwrites the broadcast.
+	SaveBroadcast(ctx context.Context, broadcast objects.Broadcast) error
+	// GetBroadcast returns ErrNotFound when there is no broadcast with the id.
+	GetBroadcast(ctx context.Context, id string) (*objects.Broadcast, error)
+	// ListBroadcasts returns up to limit latest broadcasts, the newest first.
+	ListBroadcasts(ctx context.Context, limit int) ([]objects.Broadcast, error)
+	// ListRunningBroadcasts returns all the running broadcasts, the oldest first.
+	ListRunningBroadcasts(ctx context.Context) ([]objects.Broadcast, error)
+}
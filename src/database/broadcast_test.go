@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+func TestListRunningBroadcasts(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			start := time.Now().Add(-time.Hour)
+			// The running broadcasts are older than the latest ones listed to the admin.
+			statuses := []string{objects.BroadcastRunning, objects.BroadcastFinished, objects.BroadcastRunning, objects.BroadcastCancelled,
+				objects.BroadcastDraft, objects.BroadcastFinished, objects.BroadcastFinished, objects.BroadcastFinished, objects.BroadcastFinished}
+			for i, status := range statuses {
+				broadcast := objects.Broadcast{ID: strconv.Itoa(i), CreatedAt: start.Add(time.Duration(i) * time.Minute), Status: status, Text: "text"}
+				if err := store.SaveBroadcast(ctx, broadcast); err != nil {
+					t.Fatal(err)
+				}
+			}
+			latest, err := store.ListBroadcasts(ctx, 5)
+			if err != nil || len(latest) != 5 || latest[0].ID != "8" {
+				t.Fatalf("ListBroadcasts = %+v, %v", latest, err)
+			}
+			running, err := store.ListRunningBroadcasts(ctx)
+			if err != nil || len(running) != 2 || running[0].ID != "0" || running[1].ID != "2" {
+				t.Fatalf("ListRunningBroadcasts = %+v, %v", running, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+// ConversationStore keeps the last conversation of every user with the model for follow-up questions.
+type ConversationStore interface {
+	// SaveConversation replaces the conversation of the user, it expires after ttl.
+	SaveConversation(ctx context.Context, conversation objects.Conversation, ttl time.Duration) error
+	// GetConversation returns ErrNotFound when the user has no conversation or it has expired.
+	GetConversation(ctx context.Context, userID int64) (*objects.Conversation, error)
+	DeleteConversation(ctx context.Context, userID int64) error
+}
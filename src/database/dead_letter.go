@@ -0,0 +1,17 @@
+package database
+
+import (
+	"context"
+
+	"tgbot-numerologist/objects"
+)
+
+// MaxDeadLetters is the number of the latest dead letters kept, the older ones are dropped.
+const MaxDeadLetters = 1000
+
+// DeadLetterStore keeps the messages which could not be delivered.
+type DeadLetterStore interface {
+	AddDeadLetter(ctx context.Context, letter objects.DeadLetter) error
+	// ListDeadLetters returns up to limit latest dead letters, the newest first.
+	ListDeadLetters(ctx context.Context, limit int) ([]objects.DeadLetter, error)
+}
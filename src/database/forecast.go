@@ -0,0 +1,24 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+// ForecastStore indexes the subscribers by the time their next daily forecast is due, so the scheduler
+// does not scan all the profiles. The index is updated with every saved profile, it follows
+// the subscription and its LastSent. Banned and inactive users are left out of it.
+type ForecastStore interface {
+	// DueForecasts returns up to limit users whose forecast is due at now, the longest waiting first.
+	DueForecasts(ctx context.Context, now time.Time, limit int) ([]int64, error)
+}
+
+// forecastDue returns the time the next forecast of the profile is due, false when it gets no forecasts.
+func forecastDue(profile *objects.Profile) (time.Time, bool) {
+	if profile.Subscription == nil || profile.Banned || profile.Inactive {
+		return time.Time{}, false
+	}
+	return profile.Subscription.Next(time.Now()), true
+}
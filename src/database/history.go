@@ -0,0 +1,17 @@
+package database
+
+import (
+	"context"
+
+	"tgbot-numerologist/objects"
+)
+
+// HistoryStore keeps the predictions made for every user.
+type HistoryStore interface {
+	AddPrediction(ctx context.Context, prediction objects.Prediction) error
+	// ListPredictions returns a page of predictions of the user, the newest first, and the total number of predictions.
+	ListPredictions(ctx context.Context, userID int64, offset, limit int) ([]objects.Prediction, int, error)
+	// GetPrediction returns ErrNotFound when the user has no such prediction.
+	GetPrediction(ctx context.Context, userID int64, id string) (*objects.Prediction, error)
+	DeletePrediction(ctx context.Context, userID int64, id string) error
+}
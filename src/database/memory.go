@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+// MemoryStore keeps everything in process memory. It is meant for tests and local runs.
+type MemoryStore struct {
+	mu            sync.Mutex
+	profiles      map[int64][]byte
+	usernames     map[string]int64
+	quotas        map[int64]*Quota
+	payments      map[string]objects.Payment
+	pending       map[string]bool
+	history       map[int64][]objects.Prediction
+	conversations map[int64]memoryConversation
+	audit         []objects.AuditRecord
+	broadcasts    map[string]objects.Broadcast
+	deadLetters   []objects.DeadLetter
+	forecasts     map[int64]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		profiles:      make(map[int64][]byte),
+		usernames:     make(map[string]int64),
+		quotas:        make(map[int64]*Quota),
+		payments:      make(map[string]objects.Payment),
+		pending:       make(map[string]bool),
+		history:       make(map[int64][]objects.Prediction),
+		conversations: make(map[int64]memoryConversation),
+		broadcasts:    make(map[string]objects.Broadcast),
+		forecasts:     make(map[int64]time.Time),
+	}
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// Profiles are kept serialized, so callers never share memory with the store.
+func (s *MemoryStore) getProfile(userID int64) (*objects.Profile, error) {
+	data, ok := s.profiles[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return decodeProfile(data)
+}
+
+func (s *MemoryStore) saveProfile(profile *objects.Profile) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	s.deleteUsername(profile.UserID)
+	s.profiles[profile.UserID] = data
+	due, ok := forecastDue(profile)
+	s.indexForecast(profile.UserID, due, ok)
+	if profile.Username != "" {
+		s.usernames[normalizeUsername(profile.Username)] = profile.UserID
+	}
+	return nil
+}
+
+// deleteUsername removes the current username of the user from the index.
+func (s *MemoryStore) deleteUsername(userID int64) {
+	old, err := s.getProfile(userID)
+	if err != nil || old.Username == "" {
+		return
+	}
+	username := normalizeUsername(old.Username)
+	if s.usernames[username] == userID {
+		delete(s.usernames, username)
+	}
+}
+
+func (s *MemoryStore) GetProfile(ctx context.Context, userID int64) (*objects.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getProfile(userID)
+}
+
+func (s *MemoryStore) SaveProfile(ctx context.Context, profile *objects.Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveProfile(profile)
+}
+
+func (s *MemoryStore) ProfileExists(ctx context.Context, userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.profiles[userID]
+	return ok, nil
+}
+
+func (s *MemoryStore) DeleteProfile(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteUsername(userID)
+	delete(s.profiles, userID)
+	delete(s.forecasts, userID)
+	return nil
+}
+
+func (s *MemoryStore) ListProfiles(ctx context.Context, afterID int64, limit int) ([]*objects.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id := range s.profiles {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	profiles := make([]*objects.Profile, 0, len(ids))
+	for _, id := range ids {
+		profile, err := s.getProfile(id)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+func (s *MemoryStore) UpdateProfile(ctx context.Context, userID int64, update func(profile *objects.Profile) error) (*objects.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, err := s.getProfile(userID)
+	if err == ErrNotFound {
+		profile, err = &objects.Profile{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := update(profile); err != nil {
+		return nil, err
+	}
+	profile.UserID = userID
+	if err := s.saveProfile(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (s *MemoryStore) GetUserIDByUsername(ctx context.Context, username string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userID, ok := s.usernames[normalizeUsername(username)]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return userID, nil
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *MemoryStore) AddAuditRecord(ctx context.Context, record objects.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = append(s.audit, record)
+	if len(s.audit) > MaxAuditRecords {
+		s.audit = slices.Delete(s.audit, 0, len(s.audit)-MaxAuditRecords)
+	}
+	return nil
+}
+
+func (s *MemoryStore) ListAuditRecords(ctx context.Context, limit int) ([]objects.AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := slices.Clone(s.audit[max(len(s.audit)-limit, 0):])
+	slices.Reverse(records)
+	return records, nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *MemoryStore) SaveBroadcast(ctx context.Context, broadcast objects.Broadcast) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	broadcast.Buttons = slices.Clone(broadcast.Buttons)
+	s.broadcasts[broadcast.ID] = broadcast
+	return nil
+}
+
+func (s *MemoryStore) GetBroadcast(ctx context.Context, id string) (*objects.Broadcast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	broadcast, ok := s.broadcasts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	broadcast.Buttons = slices.Clone(broadcast.Buttons)
+	return &broadcast, nil
+}
+
+func (s *MemoryStore) ListBroadcasts(ctx context.Context, limit int) ([]objects.Broadcast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var broadcasts []objects.Broadcast
+	for _, broadcast := range s.broadcasts {
+		broadcasts = append(broadcasts, broadcast)
+	}
+	slices.SortFunc(broadcasts, func(a, b objects.Broadcast) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return broadcasts[:min(limit, len(broadcasts))], nil
+}
+
+func (s *MemoryStore) ListRunningBroadcasts(ctx context.Context) ([]objects.Broadcast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var broadcasts []objects.Broadcast
+	for _, broadcast := range s.broadcasts {
+		if broadcast.Status == objects.BroadcastRunning {
+			broadcast.Buttons = slices.Clone(broadcast.Buttons)
+			broadcasts = append(broadcasts, broadcast)
+		}
+	}
+	slices.SortFunc(broadcasts, func(a, b objects.Broadcast) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return broadcasts, nil
+}
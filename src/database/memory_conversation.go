@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+type memoryConversation struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+func (s *MemoryStore) SaveConversation(ctx context.Context, conversation objects.Conversation, ttl time.Duration) error {
+	data, err := json.Marshal(conversation)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for userID, saved := range s.conversations {
+		if !saved.expiresAt.After(now) {
+			delete(s.conversations, userID)
+		}
+	}
+	s.conversations[conversation.UserID] = memoryConversation{data: data, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) GetConversation(ctx context.Context, userID int64) (*objects.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved, ok := s.conversations[userID]
+	if !ok || !saved.expiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	var conversation objects.Conversation
+	if err := json.Unmarshal(saved.data, &conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (s *MemoryStore) DeleteConversation(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conversations, userID)
+	return nil
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *MemoryStore) AddDeadLetter(ctx context.Context, letter objects.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetters = append(s.deadLetters, letter)
+	if len(s.deadLetters) > MaxDeadLetters {
+		s.deadLetters = slices.Delete(s.deadLetters, 0, len(s.deadLetters)-MaxDeadLetters)
+	}
+	return nil
+}
+
+func (s *MemoryStore) ListDeadLetters(ctx context.Context, limit int) ([]objects.DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	letters := slices.Clone(s.deadLetters[max(len(s.deadLetters)-limit, 0):])
+	slices.Reverse(letters)
+	return letters, nil
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// indexForecast updates the forecast index with the saved profile. Must be called with the lock held.
+func (s *MemoryStore) indexForecast(userID int64, due time.Time, ok bool) {
+	if ok {
+		s.forecasts[userID] = due
+	} else {
+		delete(s.forecasts, userID)
+	}
+}
+
+func (s *MemoryStore) DueForecasts(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int64
+	for id, due := range s.forecasts {
+		if !due.After(now) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b int64) int {
+		return s.forecasts[a].Compare(s.forecasts[b])
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
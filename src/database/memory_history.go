@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *MemoryStore) AddPrediction(ctx context.Context, prediction objects.Prediction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history[prediction.UserID] = append(s.history[prediction.UserID], prediction)
+	return nil
+}
+
+func (s *MemoryStore) ListPredictions(ctx context.Context, userID int64, offset, limit int) ([]objects.Prediction, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	predictions := slices.Clone(s.history[userID])
+	slices.Reverse(predictions)
+	total := len(predictions)
+	if offset >= total {
+		return nil, total, nil
+	}
+	return predictions[offset:min(offset+limit, total)], total, nil
+}
+
+func (s *MemoryStore) GetPrediction(ctx context.Context, userID int64, id string) (*objects.Prediction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, prediction := range s.history[userID] {
+		if prediction.ID == id {
+			return &prediction, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) DeletePrediction(ctx context.Context, userID int64, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history[userID] = slices.DeleteFunc(s.history[userID], func(p objects.Prediction) bool {
+		return p.ID == id
+	})
+	return nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *MemoryStore) RecordPayment(ctx context.Context, payment objects.Payment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.payments[payment.ChargeID]; ok {
+		return nil
+	}
+	s.payments[payment.ChargeID] = payment
+	s.pending[payment.ChargeID] = true
+	return nil
+}
+
+func (s *MemoryStore) CreditPayment(ctx context.Context, payment objects.Payment) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.payments[payment.ChargeID]; ok {
+		if !s.pending[payment.ChargeID] {
+			return false, nil
+		}
+		delete(s.pending, payment.ChargeID)
+		payment = s.payments[payment.ChargeID]
+	}
+	s.payments[payment.ChargeID] = payment
+	s.quota(payment.UserID).Available += payment.Quota
+	return true, nil
+}
+
+func (s *MemoryStore) PendingPayments(ctx context.Context, limit int) ([]objects.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var payments []objects.Payment
+	for chargeID := range s.pending {
+		payments = append(payments, s.payments[chargeID])
+	}
+	slices.SortFunc(payments, func(a, b objects.Payment) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	if len(payments) > limit {
+		payments = payments[:limit]
+	}
+	return payments, nil
+}
+
+func (s *MemoryStore) ListPayments(ctx context.Context, userID int64) ([]objects.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var payments []objects.Payment
+	for _, payment := range s.payments {
+		if payment.UserID == userID {
+			payments = append(payments, payment)
+		}
+	}
+	slices.SortFunc(payments, func(a, b objects.Payment) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return payments, nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *MemoryStore) InitQuota(ctx context.Context, userID int64, available int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.quotas[userID]; !ok {
+		s.quotas[userID] = &Quota{Available: available}
+	}
+	return nil
+}
+
+// quota returns the counters of the user creating them if needed. Must be called with the lock held.
+func (s *MemoryStore) quota(userID int64) *Quota {
+	quota, ok := s.quotas[userID]
+	if !ok {
+		quota = &Quota{}
+		s.quotas[userID] = quota
+	}
+	return quota
+}
+
+func (s *MemoryStore) GetQuota(ctx context.Context, userID int64) (Quota, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.quota(userID), nil
+}
+
+func (s *MemoryStore) AddQuota(ctx context.Context, userID int64, n int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quota := s.quota(userID)
+	quota.Available += n
+	return quota.Available, nil
+}
+
+func (s *MemoryStore) ReserveQuota(ctx context.Context, userID int64, cost int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quota := s.quota(userID)
+	if quota.Available < cost {
+		return ErrQuotaExhausted
+	}
+	quota.Available -= cost
+	return nil
+}
+
+func (s *MemoryStore) CommitQuota(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quota(userID).Predictions++
+	return nil
+}
+
+func (s *MemoryStore) RefundQuota(ctx context.Context, userID int64, cost int64) error {
+	_, err := s.AddQuota(ctx, userID, cost)
+	return err
+}
+
+func (s *MemoryStore) ReservePart(ctx context.Context, userID int64, cost int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quota := s.quota(userID)
+	units := missingUnits(quota.Credit, cost)
+	if quota.Available < units {
+		return ErrQuotaExhausted
+	}
+	quota.Available -= units
+	quota.Credit += units*objects.QuotaUnit - cost
+	return nil
+}
+
+func (s *MemoryStore) RefundPart(ctx context.Context, userID int64, cost int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quota := s.quota(userID)
+	quota.Credit += cost
+	quota.Available += quota.Credit / objects.QuotaUnit
+	quota.Credit %= objects.QuotaUnit
+	return nil
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"context"
+
+	"tgbot-numerologist/objects"
+)
+
+// PaymentStore keeps the ledger of payments.
+type PaymentStore interface {
+	// RecordPayment records the payment as pending before its quota is credited,
+	// so the charge is not lost when crediting fails. A recorded charge id is ignored.
+	RecordPayment(ctx context.Context, payment objects.Payment) error
+	// CreditPayment records the payment unless it is recorded and adds its quota to the user in one step.
+	// An already credited payment is ignored and false is returned.
+	CreditPayment(ctx context.Context, payment objects.Payment) (bool, error)
+	// PendingPayments returns up to limit recorded payments which are not credited yet, the oldest first.
+	PendingPayments(ctx context.Context, limit int) ([]objects.Payment, error)
+	// ListPayments returns payments of the user, the newest first.
+	ListPayments(ctx context.Context, userID int64) ([]objects.Payment, error)
+}
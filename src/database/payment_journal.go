@@ -0,0 +1,127 @@
+package database
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+
+	"tgbot-numerologist/objects"
+)
+
+// PaymentJournal keeps the payments which could not be recorded in the store in a local file,
+// so they survive a restart until the store is back. Every line of the file is a payment in JSON.
+type PaymentJournal struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewPaymentJournal(path string) *PaymentJournal {
+	return &PaymentJournal{path: path}
+}
+
+// Add appends the payment to the journal, it is on the disk when Add returns.
+func (j *PaymentJournal) Add(payment objects.Payment) error {
+	data, err := json.Marshal(payment)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	// The leading newline ends a line cut by a crash, so it does not spoil this one.
+	line := append(append([]byte{'\n'}, data...), '\n')
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// List returns the payments of the journal in the order they were added.
+func (j *PaymentJournal) List() ([]objects.Payment, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.read()
+}
+
+// Remove drops the payments with the charge ids from the journal.
+func (j *PaymentJournal) Remove(chargeIDs ...string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	payments, err := j.read()
+	if err != nil {
+		return err
+	}
+	payments = slices.DeleteFunc(payments, func(payment objects.Payment) bool {
+		return slices.Contains(chargeIDs, payment.ChargeID)
+	})
+	if len(payments) == 0 {
+		err := os.Remove(j.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	// The journal is replaced at once, so a crash never leaves it half written.
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, payment := range payments {
+		data, err := json.Marshal(payment)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+func (j *PaymentJournal) read() ([]objects.Payment, error) {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var payments []objects.Payment
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var payment objects.Payment
+		// A line cut by a crash during Add is skipped, the payment was not acknowledged as recorded.
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &payment); err != nil || payment.ChargeID == "" {
+			continue
+		}
+		payments = append(payments, payment)
+	}
+	return payments, scanner.Err()
+}
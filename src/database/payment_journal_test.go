@@ -0,0 +1,49 @@
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+func TestPaymentJournal(t *testing.T) {
+	path := t.TempDir() + "/payments.jsonl"
+	journal := NewPaymentJournal(path)
+	if payments, err := journal.List(); err != nil || len(payments) != 0 {
+		t.Fatalf("List of a missing journal = %+v, %v", payments, err)
+	}
+	for _, chargeID := range []string{"a", "b", "c"} {
+		payment := objects.Payment{ChargeID: chargeID, UserID: 1, Quota: 5, Amount: 200, Currency: objects.StarsCurrency, CreatedAt: time.Now()}
+		if err := journal.Add(payment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A line cut by a crash is skipped.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"charge_id":"d","us`)
+	f.Close()
+	if err := journal.Add(objects.Payment{ChargeID: "e", UserID: 2, Quota: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The journal is read again after the restart.
+	journal = NewPaymentJournal(path)
+	payments, err := journal.List()
+	if err != nil || len(payments) != 4 || payments[0].ChargeID != "a" || payments[2].Quota != 5 || payments[3].ChargeID != "e" {
+		t.Fatalf("List = %+v, %v", payments, err)
+	}
+	if err := journal.Remove("a", "c", "e", "unknown"); err != nil {
+		t.Fatal(err)
+	}
+	if payments, err := journal.List(); err != nil || len(payments) != 1 || payments[0].ChargeID != "b" {
+		t.Fatalf("List after Remove = %+v, %v", payments, err)
+	}
+	if err := journal.Remove("b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the empty journal is kept: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+func TestPayments(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().Truncate(time.Second)
+			first := objects.Payment{ChargeID: "a", UserID: 1, PackageID: "small", Quota: 1, Amount: 50, Currency: objects.StarsCurrency, CreatedAt: now.Add(-time.Minute)}
+			second := objects.Payment{ChargeID: "b", UserID: 1, PackageID: "big", Quota: 10, Amount: 350, Currency: objects.StarsCurrency, CreatedAt: now}
+			direct := objects.Payment{ChargeID: "c", UserID: 2, PackageID: "small", Quota: 1, Amount: 50, Currency: objects.StarsCurrency, CreatedAt: now}
+
+			// Recorded payments are pending until they are credited, recording twice changes nothing.
+			for _, payment := range []objects.Payment{second, first, second} {
+				if err := store.RecordPayment(ctx, payment); err != nil {
+					t.Fatal(err)
+				}
+			}
+			pending, err := store.PendingPayments(ctx, 10)
+			if err != nil || len(pending) != 2 || pending[0].ChargeID != "a" || pending[1].ChargeID != "b" {
+				t.Fatalf("PendingPayments = %+v, %v", pending, err)
+			}
+			if pending, _ := store.PendingPayments(ctx, 1); len(pending) != 1 {
+				t.Errorf("PendingPayments over the limit: %+v", pending)
+			}
+			if quota, _ := store.GetQuota(ctx, 1); quota.Available != 0 {
+				t.Errorf("recorded payments are credited: %+v", quota)
+			}
+
+			steps := []struct {
+				payment  objects.Payment
+				credited bool
+			}{
+				{first, true},
+				{first, false},
+				{direct, true},
+				{direct, false},
+			}
+			for _, step := range steps {
+				credited, err := store.CreditPayment(ctx, step.payment)
+				if err != nil || credited != step.credited {
+					t.Fatalf("CreditPayment(%s) = %v, %v, want %v", step.payment.ChargeID, credited, err, step.credited)
+				}
+			}
+			pending, err = store.PendingPayments(ctx, 10)
+			if err != nil || len(pending) != 1 || pending[0].ChargeID != "b" || pending[0].Quota != 10 {
+				t.Fatalf("PendingPayments after crediting = %+v, %v", pending, err)
+			}
+			if credited, err := store.CreditPayment(ctx, pending[0]); err != nil || !credited {
+				t.Fatalf("CreditPayment of the pending payment = %v, %v", credited, err)
+			}
+			if pending, _ := store.PendingPayments(ctx, 10); len(pending) != 0 {
+				t.Errorf("PendingPayments after crediting all = %+v", pending)
+			}
+
+			for userID, want := range map[int64]int64{1: 11, 2: 1} {
+				if quota, err := store.GetQuota(ctx, userID); err != nil || quota.Available != want {
+					t.Errorf("quota of user %d = %+v, %v, want %d", userID, quota, err, want)
+				}
+			}
+			payments, err := store.ListPayments(ctx, 1)
+			if err != nil || len(payments) != 2 || payments[0].ChargeID != "b" {
+				t.Errorf("ListPayments = %+v, %v", payments, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"tgbot-numerologist/objects"
+)
+
+var ErrQuotaExhausted = errors.New("quota exhausted")
+
+type Quota struct {
+	// Available is the number of predictions the user can still request.
+	Available int64
+	// Predictions is the number of predictions already made.
+	Predictions int64
+	// Credit is the part of a prediction already paid and not spent yet, in objects.QuotaUnit parts.
+	Credit int64
+}
+
+// QuotaStore keeps quota counters separately from the profile, so they are changed atomically
+// and never overwritten by a profile save.
+type QuotaStore interface {
+	// InitQuota sets available quota of a new user, existing counters are kept as is.
+	InitQuota(ctx context.Context, userID int64, available int64) error
+	GetQuota(ctx context.Context, userID int64) (Quota, error)
+	// AddQuota increases available quota and returns the new value.
+	AddQuota(ctx context.Context, userID int64, n int64) (int64, error)
+	// ReserveQuota takes cost from available quota or returns ErrQuotaExhausted without changes.
+	ReserveQuota(ctx context.Context, userID int64, cost int64) error
+	// CommitQuota counts the prediction the quota was reserved for.
+	CommitQuota(ctx context.Context, userID int64) error
+	// RefundQuota returns reserved cost to available quota.
+	RefundQuota(ctx context.Context, userID int64, cost int64) error
+	// ReservePart takes cost, in objects.QuotaUnit parts of a prediction, from the credit. The whole predictions
+	// missing in the credit are taken from available quota in the same step, their rest stays in the credit.
+	// Returns ErrQuotaExhausted without changes when available quota is not enough.
+	ReservePart(ctx context.Context, userID int64, cost int64) error
+	// RefundPart returns cost to the credit, the whole predictions collected there go back to available quota.
+	RefundPart(ctx context.Context, userID int64, cost int64) error
+}
+
+// missingUnits is the number of whole predictions to add to credit to pay cost.
+func missingUnits(credit, cost int64) int64 {
+	if credit >= cost {
+		return 0
+	}
+	return (cost - credit + objects.QuotaUnit - 1) / objects.QuotaUnit
+}
+
+// Reservation is quota taken for a single prediction. It has to be either committed
+// when the prediction is delivered or refunded when it failed, only the first call has effect.
+type Reservation struct {
+	store  QuotaStore
+	userID int64
+	cost   int64
+	once   sync.Once
+}
+
+func Reserve(ctx context.Context, store QuotaStore, userID int64, cost int64) (*Reservation, error) {
+	if err := store.ReserveQuota(ctx, userID, cost); err != nil {
+		return nil, err
+	}
+	return &Reservation{store: store, userID: userID, cost: cost}, nil
+}
+
+func (r *Reservation) Commit(ctx context.Context) error {
+	var err error
+	r.once.Do(func() {
+		err = r.store.CommitQuota(ctx, r.userID)
+	})
+	return err
+}
+
+func (r *Reservation) Refund(ctx context.Context) error {
+	var err error
+	r.once.Do(func() {
+		err = r.store.RefundQuota(ctx, r.userID, r.cost)
+	})
+	return err
+}
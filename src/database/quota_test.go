@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"tgbot-numerologist/objects"
+)
+
+func TestQuota(t *testing.T) {
+	type step struct {
+		op          string
+		n           int64
+		err         error
+		available   int64
+		predictions int64
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"init keeps counters", []step{
+			{"init", 3, nil, 3, 0},
+			{"reserve", 1, nil, 2, 0},
+			{"init", 3, nil, 2, 0},
+		}},
+		{"reserve and commit", []step{
+			{"init", 3, nil, 3, 0},
+			{"reserve", 2, nil, 1, 0},
+			{"commit", 0, nil, 1, 1},
+		}},
+		{"reserve and refund", []step{
+			{"init", 3, nil, 3, 0},
+			{"reserve", 2, nil, 1, 0},
+			{"refund", 2, nil, 3, 0},
+		}},
+		{"exhausted", []step{
+			{"init", 1, nil, 1, 0},
+			{"reserve", 2, ErrQuotaExhausted, 1, 0},
+			{"reserve", 1, nil, 0, 0},
+			{"reserve", 1, ErrQuotaExhausted, 0, 0},
+		}},
+		{"add", []step{
+			{"init", 1, nil, 1, 0},
+			{"add", 5, nil, 6, 0},
+			{"reserve", 6, nil, 0, 0},
+		}},
+		{"without init", []step{
+			{"reserve", 1, ErrQuotaExhausted, 0, 0},
+			{"commit", 0, nil, 0, 1},
+			{"add", 2, nil, 2, 1},
+		}},
+	}
+	for name, store := range stores(t) {
+		for i, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				userID := int64(i + 1)
+				for j, s := range tt.steps {
+					var err error
+					switch s.op {
+					case "init":
+						err = store.InitQuota(ctx, userID, s.n)
+					case "add":
+						_, err = store.AddQuota(ctx, userID, s.n)
+					case "reserve":
+						err = store.ReserveQuota(ctx, userID, s.n)
+					case "commit":
+						err = store.CommitQuota(ctx, userID)
+					case "refund":
+						err = store.RefundQuota(ctx, userID, s.n)
+					}
+					if !errors.Is(err, s.err) {
+						t.Fatalf("step %d %s(%d) error = %v, want %v", j, s.op, s.n, err, s.err)
+					}
+					quota, err := store.GetQuota(ctx, userID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if quota.Available != s.available || quota.Predictions != s.predictions {
+						t.Fatalf("step %d %s(%d) left %+v, want available %d, predictions %d",
+							j, s.op, s.n, quota, s.available, s.predictions)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestReservation(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store.InitQuota(ctx, 1, 2)
+
+			committed, err := Reserve(ctx, store, 1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			committed.Commit(ctx)
+			// Only the first call has effect.
+			committed.Refund(ctx)
+
+			refunded, err := Reserve(ctx, store, 1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			refunded.Refund(ctx)
+			refunded.Refund(ctx)
+			refunded.Commit(ctx)
+
+			quota, _ := store.GetQuota(ctx, 1)
+			if quota.Available != 1 || quota.Predictions != 1 {
+				t.Errorf("got %+v, want available 1, predictions 1", quota)
+			}
+			if _, err := Reserve(ctx, store, 1, 2); !errors.Is(err, ErrQuotaExhausted) {
+				t.Errorf("Reserve over the quota: %v", err)
+			}
+		})
+	}
+}
+
+func TestReserveConcurrently(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store.InitQuota(ctx, 1, 5)
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			reserved := 0
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := store.ReserveQuota(ctx, 1, 1); err == nil {
+						mu.Lock()
+						reserved++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			quota, _ := store.GetQuota(ctx, 1)
+			if reserved != 5 || quota.Available != 0 {
+				t.Errorf("reserved %d of 5, %d left", reserved, quota.Available)
+			}
+		})
+	}
+}
+
+func TestReservePart(t *testing.T) {
+	const quarter = objects.QuotaUnit / 4
+	type step struct {
+		op        string
+		cost      int64
+		err       error
+		available int64
+		credit    int64
+	}
+	steps := []step{
+		{"reserve", quarter, nil, 1, 3 * quarter},
+		{"reserve", quarter, nil, 1, 2 * quarter},
+		{"reserve", 3 * quarter, nil, 0, 3 * quarter},
+		{"reserve", 3 * quarter, nil, 0, 0},
+		{"reserve", quarter, ErrQuotaExhausted, 0, 0},
+		{"refund", 3 * quarter, nil, 0, 3 * quarter},
+		{"refund", 3 * quarter, nil, 1, 2 * quarter},
+		{"reserve", 2 * objects.QuotaUnit, ErrQuotaExhausted, 1, 2 * quarter},
+	}
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store.InitQuota(ctx, 1, 2)
+			for i, s := range steps {
+				var err error
+				if s.op == "reserve" {
+					err = store.ReservePart(ctx, 1, s.cost)
+				} else {
+					err = store.RefundPart(ctx, 1, s.cost)
+				}
+				if !errors.Is(err, s.err) {
+					t.Fatalf("step %d %s(%d) error = %v, want %v", i, s.op, s.cost, err, s.err)
+				}
+				quota, err := store.GetQuota(ctx, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if quota.Available != s.available || quota.Credit != s.credit {
+					t.Fatalf("step %d %s(%d) left %+v, want available %d, credit %d",
+						i, s.op, s.cost, quota, s.available, s.credit)
+				}
+			}
+		})
+	}
+}
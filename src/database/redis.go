@@ -0,0 +1,417 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/utils"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	profileKeyPrefix  = "profile:"
+	usernameKeyPrefix = "username:"
+	// profilesKey is a sorted set of all user ids used for listing.
+	profilesKey          = "profiles"
+	quotaKeyPrefix       = "quota:"
+	predictionsKeyPrefix = "predictions:"
+	creditKeyPrefix      = "quota_credit:"
+	paymentKeyPrefix     = "payment:"
+	paymentsKeyPrefix    = "payments:"
+	// pendingPaymentsKey is a sorted set of the charge ids recorded but not credited, scored by the payment time.
+	pendingPaymentsKey      = "payments_pending"
+	historyKeyPrefix        = "history:"
+	predictionDataKeyPrefix = "history_data:"
+	conversationKeyPrefix   = "conversation:"
+	// auditKey is a list of the admin commands, the newest first.
+	auditKey           = "audit:log"
+	broadcastKeyPrefix = "broadcast:"
+	// broadcastsKey is a sorted set of the broadcast ids by the creation time.
+	broadcastsKey = "broadcasts"
+	// forecastsKey is the sorted set of the subscribers scored by the unix time their forecast is due.
+	forecastsKey = "forecasts:due"
+	// deadLettersKey is a list of the undelivered messages, the newest first.
+	deadLettersKey = "dead_letter:log"
+	// schemaVersionKey is the number of redisMigrations applied to the database.
+	schemaVersionKey = "meta:schema_version"
+
+	maxTxRetries = 10
+)
+
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(addr string) (*RedisStore, error) {
+	s := &RedisStore{
+		rdb: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+	}
+	if err := s.migrate(context.Background()); err != nil {
+		s.rdb.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.rdb.Close()
+}
+
+func profileKey(userID int64) string {
+	return profileKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+func usernameKey(username string) string {
+	return usernameKeyPrefix + normalizeUsername(username)
+}
+
+func decodeProfile(data []byte) (*objects.Profile, error) {
+	var profile objects.Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (s *RedisStore) GetProfile(ctx context.Context, userID int64) (*objects.Profile, error) {
+	data, err := s.rdb.Get(ctx, profileKey(userID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeProfile(data)
+}
+
+// writeProfile queues the commands saving the profile. old is the stored version, if any.
+func writeProfile(ctx context.Context, pipe redis.Pipeliner, profile, old *objects.Profile) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	pipe.Set(ctx, profileKey(profile.UserID), data, 0)
+	pipe.ZAdd(ctx, profilesKey, &redis.Z{Score: float64(profile.UserID), Member: profile.UserID})
+	if old != nil && old.Username != "" && normalizeUsername(old.Username) != normalizeUsername(profile.Username) {
+		// The old username may already belong to someone else.
+		pipe.Eval(ctx, deleteIfEqualScript, []string{usernameKey(old.Username)}, profile.UserID)
+	}
+	if profile.Username != "" {
+		pipe.Set(ctx, usernameKey(profile.Username), profile.UserID, 0)
+	}
+	if due, ok := forecastDue(profile); ok {
+		pipe.ZAdd(ctx, forecastsKey, &redis.Z{Score: float64(due.Unix()), Member: profile.UserID})
+	} else {
+		pipe.ZRem(ctx, forecastsKey, profile.UserID)
+	}
+	return nil
+}
+
+// deleteIfEqualScript deletes KEYS[1] only when it holds ARGV[1].
+const deleteIfEqualScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+func (s *RedisStore) SaveProfile(ctx context.Context, profile *objects.Profile) error {
+	_, err := s.UpdateProfile(ctx, profile.UserID, func(p *objects.Profile) error {
+		*p = *profile
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) ProfileExists(ctx context.Context, userID int64) (bool, error) {
+	n, err := s.rdb.Exists(ctx, profileKey(userID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *RedisStore) DeleteProfile(ctx context.Context, userID int64) error {
+	profile, err := s.GetProfile(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pipe := s.rdb.TxPipeline()
+	pipe.Del(ctx, profileKey(userID))
+	pipe.ZRem(ctx, profilesKey, userID)
+	pipe.ZRem(ctx, forecastsKey, userID)
+	if profile.Username != "" {
+		pipe.Eval(ctx, deleteIfEqualScript, []string{usernameKey(profile.Username)}, userID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStore) ListProfiles(ctx context.Context, afterID int64, limit int) ([]*objects.Profile, error) {
+	ids, err := s.rdb.ZRangeByScore(ctx, profilesKey, &redis.ZRangeBy{
+		Min:   "(" + strconv.FormatInt(afterID, 10),
+		Max:   "+inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = profileKeyPrefix + id
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]*objects.Profile, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			utils.Log("profile %s is listed but not stored", ids[i])
+			continue
+		}
+		profile, err := decodeProfile([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// UpdateProfile uses optimistic locking, the update is retried when the profile is changed concurrently.
+// A new empty profile with the user id is passed to update when the user has no profile yet.
+func (s *RedisStore) UpdateProfile(ctx context.Context, userID int64, update func(profile *objects.Profile) error) (*objects.Profile, error) {
+	key := profileKey(userID)
+	var result *objects.Profile
+	txf := func(tx *redis.Tx) error {
+		var old *objects.Profile
+		data, err := tx.Get(ctx, key).Bytes()
+		switch {
+		case err == redis.Nil:
+		case err != nil:
+			return err
+		default:
+			if old, err = decodeProfile(data); err != nil {
+				return err
+			}
+		}
+		profile := &objects.Profile{UserID: userID}
+		if old != nil {
+			copied := *old
+			profile = &copied
+		}
+		if err := update(profile); err != nil {
+			return err
+		}
+		profile.UserID = userID
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return writeProfile(ctx, pipe, profile, old)
+		})
+		if err != nil {
+			return err
+		}
+		result = profile
+		return nil
+	}
+	for range maxTxRetries {
+		err := s.rdb.Watch(ctx, txf, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return nil, errors.New("too many concurrent profile updates")
+}
+
+func (s *RedisStore) GetUserIDByUsername(ctx context.Context, username string) (int64, error) {
+	userID, err := s.rdb.Get(ctx, usernameKey(username)).Int64()
+	if err == redis.Nil {
+		return 0, ErrNotFound
+	}
+	return userID, err
+}
+
+// redisMigrations are run once each in order, the number of the applied ones is stored in schemaVersionKey.
+// Databases created before the version key run all of them, so they must be idempotent.
+var redisMigrations = []func(s *RedisStore, ctx context.Context) error{
+	(*RedisStore).migrateProfiles,
+	(*RedisStore).migrateForecasts,
+}
+
+// migrate runs the migrations the database has not applied yet.
+func (s *RedisStore) migrate(ctx context.Context) error {
+	version, err := s.rdb.Get(ctx, schemaVersionKey).Int()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	for ; version < len(redisMigrations); version++ {
+		if err := redisMigrations[version](s, ctx); err != nil {
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if err := s.rdb.Set(ctx, schemaVersionKey, version+1, 0).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyKey reports whether key may hold a profile stored under the username before the user id keys.
+// Such keys have no prefix, the other keys without a colon are the indexes.
+func legacyKey(key string) bool {
+	switch key {
+	case profilesKey, pendingPaymentsKey, broadcastsKey:
+		return false
+	}
+	return !strings.Contains(key, ":")
+}
+
+// migrateProfiles moves profiles stored under the username key to the user id key and fills the profile list.
+// Old records have no user id, but the bot works only in private chats where chat id equals user id.
+func (s *RedisStore) migrateProfiles(ctx context.Context) error {
+	var legacyKeys, profileKeys []string
+	iter := s.rdb.Scan(ctx, 0, "*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		switch {
+		case strings.HasPrefix(key, profileKeyPrefix):
+			profileKeys = append(profileKeys, key)
+		case legacyKey(key):
+			legacyKeys = append(legacyKeys, key)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	for _, key := range profileKeys {
+		userID, err := strconv.ParseInt(strings.TrimPrefix(key, profileKeyPrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		if err := s.rdb.ZAdd(ctx, profilesKey, &redis.Z{Score: float64(userID), Member: userID}).Err(); err != nil {
+			return err
+		}
+		if err := s.migrateQuota(ctx, key, userID); err != nil {
+			return err
+		}
+	}
+
+	migrated := 0
+	for _, key := range legacyKeys {
+		keyType, err := s.rdb.Type(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if keyType != "string" {
+			continue
+		}
+		data, err := s.rdb.Get(ctx, key).Bytes()
+		if err != nil {
+			utils.Log("migration: skip key %q: %v", key, err)
+			continue
+		}
+		profile, err := decodeProfile(data)
+		if err != nil {
+			utils.Log("migration: skip key %q, not a profile: %v", key, err)
+			continue
+		}
+		if profile.UserID == 0 {
+			profile.UserID = profile.ChatID
+		}
+		if profile.UserID == 0 {
+			utils.Log("migration: skip key %q without chat id", key)
+			continue
+		}
+		exists, err := s.ProfileExists(ctx, profile.UserID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err := s.SaveProfile(ctx, profile); err != nil {
+				return err
+			}
+			if err := s.migrateQuota(ctx, key, profile.UserID); err != nil {
+				return err
+			}
+		}
+		if err := s.rdb.Del(ctx, key).Err(); err != nil {
+			return err
+		}
+		migrated++
+	}
+	if migrated > 0 {
+		utils.Log("migration: moved %d profiles to user id keys", migrated)
+	}
+	return nil
+}
+
+// migrateForecasts adds the subscriptions saved before the forecast index existed to the index.
+func (s *RedisStore) migrateForecasts(ctx context.Context) error {
+	iter := s.rdb.Scan(ctx, 0, profileKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := s.migrateForecast(ctx, iter.Val()); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// migrateForecast adds the subscription of the profile stored under key to the forecast index,
+// if it was saved before the index existed.
+func (s *RedisStore) migrateForecast(ctx context.Context, key string) error {
+	data, err := s.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		return err
+	}
+	profile, err := decodeProfile(data)
+	if err != nil {
+		return nil
+	}
+	due, ok := forecastDue(profile)
+	if !ok {
+		return nil
+	}
+	return s.rdb.ZAddNX(ctx, forecastsKey, &redis.Z{Score: float64(due.Unix()), Member: profile.UserID}).Err()
+}
+
+// legacyCounters are the quota fields stored in the profile before they got their own keys.
+type legacyCounters struct {
+	Quote       *int64 `json:"quote"`
+	Predictions int64  `json:"predictions"`
+}
+
+// migrateQuota copies quota counters from the profile stored under key, if the user has no counters yet.
+func (s *RedisStore) migrateQuota(ctx context.Context, key string, userID int64) error {
+	data, err := s.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		return err
+	}
+	var counters legacyCounters
+	if err := json.Unmarshal(data, &counters); err != nil || counters.Quote == nil {
+		return nil
+	}
+	pipe := s.rdb.TxPipeline()
+	pipe.SetNX(ctx, quotaKey(userID), *counters.Quote, 0)
+	pipe.SetNX(ctx, predictionsKey(userID), counters.Predictions, 0)
+	_, err = pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"tgbot-numerologist/objects"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func (s *RedisStore) AddAuditRecord(ctx context.Context, record objects.AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, auditKey, data)
+		pipe.LTrim(ctx, auditKey, 0, MaxAuditRecords-1)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) ListAuditRecords(ctx context.Context, limit int) ([]objects.AuditRecord, error) {
+	values, err := s.rdb.LRange(ctx, auditKey, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]objects.AuditRecord, 0, len(values))
+	for _, value := range values {
+		var record objects.AuditRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"tgbot-numerologist/objects"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func broadcastKey(id string) string {
+	return broadcastKeyPrefix + id
+}
+
+func (s *RedisStore) SaveBroadcast(ctx context.Context, broadcast objects.Broadcast) error {
+	data, err := json.Marshal(broadcast)
+	if err != nil {
+		return err
+	}
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, broadcastKey(broadcast.ID), data, 0)
+		pipe.ZAdd(ctx, broadcastsKey, &redis.Z{Score: float64(broadcast.CreatedAt.UnixNano()), Member: broadcast.ID})
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) GetBroadcast(ctx context.Context, id string) (*objects.Broadcast, error) {
+	data, err := s.rdb.Get(ctx, broadcastKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var broadcast objects.Broadcast
+	if err := json.Unmarshal(data, &broadcast); err != nil {
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+func (s *RedisStore) ListBroadcasts(ctx context.Context, limit int) ([]objects.Broadcast, error) {
+	ids, err := s.rdb.ZRevRange(ctx, broadcastsKey, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return s.getBroadcasts(ctx, ids)
+}
+
+// ListRunningBroadcasts reads all the broadcasts page by page, there are few of them.
+func (s *RedisStore) ListRunningBroadcasts(ctx context.Context) ([]objects.Broadcast, error) {
+	const pageSize = 100
+	var running []objects.Broadcast
+	for start := int64(0); ; start += pageSize {
+		ids, err := s.rdb.ZRange(ctx, broadcastsKey, start, start+pageSize-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		broadcasts, err := s.getBroadcasts(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, broadcast := range broadcasts {
+			if broadcast.Status == objects.BroadcastRunning {
+				running = append(running, broadcast)
+			}
+		}
+		if len(ids) < pageSize {
+			return running, nil
+		}
+	}
+}
+
+func (s *RedisStore) getBroadcasts(ctx context.Context, ids []string) ([]objects.Broadcast, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = broadcastKey(id)
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	broadcasts := make([]objects.Broadcast, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var broadcast objects.Broadcast
+		if err := json.Unmarshal([]byte(data), &broadcast); err != nil {
+			return nil, err
+		}
+		broadcasts = append(broadcasts, broadcast)
+	}
+	return broadcasts, nil
+}
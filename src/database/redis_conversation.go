@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"tgbot-numerologist/objects"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func conversationKey(userID int64) string {
+	return conversationKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+func (s *RedisStore) SaveConversation(ctx context.Context, conversation objects.Conversation, ttl time.Duration) error {
+	data, err := json.Marshal(conversation)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, conversationKey(conversation.UserID), data, ttl).Err()
+}
+
+func (s *RedisStore) GetConversation(ctx context.Context, userID int64) (*objects.Conversation, error) {
+	data, err := s.rdb.Get(ctx, conversationKey(userID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conversation objects.Conversation
+	if err := json.Unmarshal(data, &conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (s *RedisStore) DeleteConversation(ctx context.Context, userID int64) error {
+	return s.rdb.Del(ctx, conversationKey(userID)).Err()
+}
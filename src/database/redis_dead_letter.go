@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"tgbot-numerologist/objects"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func (s *RedisStore) AddDeadLetter(ctx context.Context, letter objects.DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, deadLettersKey, data)
+		pipe.LTrim(ctx, deadLettersKey, 0, MaxDeadLetters-1)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) ListDeadLetters(ctx context.Context, limit int) ([]objects.DeadLetter, error) {
+	values, err := s.rdb.LRange(ctx, deadLettersKey, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	letters := make([]objects.DeadLetter, 0, len(values))
+	for _, value := range values {
+		var letter objects.DeadLetter
+		if err := json.Unmarshal([]byte(value), &letter); err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"tgbot-numerologist/objects"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// historyKey is a sorted set of prediction ids ordered by creation time.
+func historyKey(userID int64) string {
+	return historyKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// predictionsDataKey is a hash of prediction id to the prediction.
+func predictionsDataKey(userID int64) string {
+	return predictionDataKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+func (s *RedisStore) AddPrediction(ctx context.Context, prediction objects.Prediction) error {
+	data, err := json.Marshal(prediction)
+	if err != nil {
+		return err
+	}
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, predictionsDataKey(prediction.UserID), prediction.ID, data)
+	pipe.ZAdd(ctx, historyKey(prediction.UserID), &redis.Z{
+		Score:  float64(prediction.CreatedAt.UnixMilli()),
+		Member: prediction.ID,
+	})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStore) ListPredictions(ctx context.Context, userID int64, offset, limit int) ([]objects.Prediction, int, error) {
+	pipe := s.rdb.TxPipeline()
+	idsCmd := pipe.ZRevRange(ctx, historyKey(userID), int64(offset), int64(offset+limit-1))
+	totalCmd := pipe.ZCard(ctx, historyKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, err
+	}
+	ids := idsCmd.Val()
+	if len(ids) == 0 {
+		return nil, int(totalCmd.Val()), nil
+	}
+	values, err := s.rdb.HMGet(ctx, predictionsDataKey(userID), ids...).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	predictions := make([]objects.Prediction, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var prediction objects.Prediction
+		if err := json.Unmarshal([]byte(data), &prediction); err != nil {
+			return nil, 0, err
+		}
+		predictions = append(predictions, prediction)
+	}
+	return predictions, int(totalCmd.Val()), nil
+}
+
+func (s *RedisStore) GetPrediction(ctx context.Context, userID int64, id string) (*objects.Prediction, error) {
+	data, err := s.rdb.HGet(ctx, predictionsDataKey(userID), id).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var prediction objects.Prediction
+	if err := json.Unmarshal(data, &prediction); err != nil {
+		return nil, err
+	}
+	return &prediction, nil
+}
+
+func (s *RedisStore) DeletePrediction(ctx context.Context, userID int64, id string) error {
+	pipe := s.rdb.TxPipeline()
+	pipe.HDel(ctx, predictionsDataKey(userID), id)
+	pipe.ZRem(ctx, historyKey(userID), id)
+	_, err := pipe.Exec(ctx)
+	return err
+}
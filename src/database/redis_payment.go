@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	"tgbot-numerologist/objects"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func paymentKey(chargeID string) string {
+	return paymentKeyPrefix + chargeID
+}
+
+func paymentsKey(userID int64) string {
+	return paymentsKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// recordScript stores the payment ARGV[1] in KEYS[1] unless it exists, then appends the charge id ARGV[2]
+// to the ledger KEYS[2] and adds it to the pending payments KEYS[3] with the payment time ARGV[3].
+var recordScript = redis.NewScript(`
+if redis.call("SETNX", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("LPUSH", KEYS[2], ARGV[2])
+redis.call("ZADD", KEYS[3], ARGV[3], ARGV[2])
+return 1
+`)
+
+// creditScript stores the payment ARGV[1] in KEYS[1] and appends the charge id ARGV[3] to the ledger KEYS[3]
+// unless it exists, a recorded payment is credited only while it is in the pending payments KEYS[4].
+// Then it adds quota ARGV[2] to KEYS[2].
+var creditScript = redis.NewScript(`
+if redis.call("SETNX", KEYS[1], ARGV[1]) == 1 then
+	redis.call("LPUSH", KEYS[3], ARGV[3])
+elseif redis.call("ZREM", KEYS[4], ARGV[3]) == 0 then
+	return 0
+end
+redis.call("INCRBY", KEYS[2], ARGV[2])
+return 1
+`)
+
+func (s *RedisStore) RecordPayment(ctx context.Context, payment objects.Payment) error {
+	data, err := json.Marshal(payment)
+	if err != nil {
+		return err
+	}
+	keys := []string{paymentKey(payment.ChargeID), paymentsKey(payment.UserID), pendingPaymentsKey}
+	return recordScript.Run(ctx, s.rdb, keys, data, payment.ChargeID, payment.CreatedAt.Unix()).Err()
+}
+
+func (s *RedisStore) CreditPayment(ctx context.Context, payment objects.Payment) (bool, error) {
+	data, err := json.Marshal(payment)
+	if err != nil {
+		return false, err
+	}
+	keys := []string{paymentKey(payment.ChargeID), quotaKey(payment.UserID), paymentsKey(payment.UserID), pendingPaymentsKey}
+	credited, err := creditScript.Run(ctx, s.rdb, keys, data, payment.Quota, payment.ChargeID).Int()
+	if err != nil {
+		return false, err
+	}
+	return credited == 1, nil
+}
+
+func (s *RedisStore) PendingPayments(ctx context.Context, limit int) ([]objects.Payment, error) {
+	chargeIDs, err := s.rdb.ZRange(ctx, pendingPaymentsKey, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return s.getPayments(ctx, chargeIDs)
+}
+
+func (s *RedisStore) ListPayments(ctx context.Context, userID int64) ([]objects.Payment, error) {
+	chargeIDs, err := s.rdb.LRange(ctx, paymentsKey(userID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.getPayments(ctx, chargeIDs)
+	if err != nil {
+		return nil, err
+	}
+	// The ledger is in the order the payments were stored, which differs from their time
+	// when a pending payment is recorded after a later one.
+	slices.SortStableFunc(payments, func(a, b objects.Payment) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return payments, nil
+}
+
+func (s *RedisStore) getPayments(ctx context.Context, chargeIDs []string) ([]objects.Payment, error) {
+	if len(chargeIDs) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(chargeIDs))
+	for i, chargeID := range chargeIDs {
+		keys[i] = paymentKey(chargeID)
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	payments := make([]objects.Payment, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var payment objects.Payment
+		if err := json.Unmarshal([]byte(data), &payment); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"strconv"
+
+	"tgbot-numerologist/objects"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func quotaKey(userID int64) string {
+	return quotaKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+func predictionsKey(userID int64) string {
+	return predictionsKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+func creditKey(userID int64) string {
+	return creditKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// reserveScript decrements KEYS[1] by ARGV[1] only when the result is not negative.
+var reserveScript = redis.NewScript(`
+local available = tonumber(redis.call("GET", KEYS[1]) or "0")
+if available < tonumber(ARGV[1]) then
+	return -1
+end
+return redis.call("DECRBY", KEYS[1], ARGV[1])
+`)
+
+// reservePartScript takes ARGV[1] from the credit KEYS[2], the missing whole units of ARGV[2] parts are
+// decremented from KEYS[1] when it has enough of them. Returns -1 without changes otherwise.
+var reservePartScript = redis.NewScript(`
+local credit = tonumber(redis.call("GET", KEYS[2]) or "0")
+local cost = tonumber(ARGV[1])
+local unit = tonumber(ARGV[2])
+local units = 0
+if credit < cost then
+	units = math.floor((cost - credit + unit - 1) / unit)
+	local available = tonumber(redis.call("GET", KEYS[1]) or "0")
+	if available < units then
+		return -1
+	end
+	redis.call("DECRBY", KEYS[1], units)
+end
+redis.call("SET", KEYS[2], credit + units * unit - cost)
+return units
+`)
+
+// refundPartScript adds ARGV[1] to the credit KEYS[2] and moves the whole units of ARGV[2] parts to KEYS[1].
+var refundPartScript = redis.NewScript(`
+local credit = tonumber(redis.call("GET", KEYS[2]) or "0") + tonumber(ARGV[1])
+local unit = tonumber(ARGV[2])
+local units = math.floor(credit / unit)
+if units > 0 then
+	redis.call("INCRBY", KEYS[1], units)
+end
+redis.call("SET", KEYS[2], credit - units * unit)
+return units
+`)
+
+func (s *RedisStore) InitQuota(ctx context.Context, userID int64, available int64) error {
+	return s.rdb.SetNX(ctx, quotaKey(userID), available, 0).Err()
+}
+
+func (s *RedisStore) GetQuota(ctx context.Context, userID int64) (Quota, error) {
+	values, err := s.rdb.MGet(ctx, quotaKey(userID), predictionsKey(userID), creditKey(userID)).Result()
+	if err != nil {
+		return Quota{}, err
+	}
+	var quota Quota
+	counters := []*int64{&quota.Available, &quota.Predictions, &quota.Credit}
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if *counters[i], err = strconv.ParseInt(str, 10, 64); err != nil {
+			return Quota{}, err
+		}
+	}
+	return quota, nil
+}
+
+func (s *RedisStore) AddQuota(ctx context.Context, userID int64, n int64) (int64, error) {
+	return s.rdb.IncrBy(ctx, quotaKey(userID), n).Result()
+}
+
+func (s *RedisStore) ReserveQuota(ctx context.Context, userID int64, cost int64) error {
+	left, err := reserveScript.Run(ctx, s.rdb, []string{quotaKey(userID)}, cost).Int64()
+	if err != nil {
+		return err
+	}
+	if left < 0 {
+		return ErrQuotaExhausted
+	}
+	return nil
+}
+
+func (s *RedisStore) CommitQuota(ctx context.Context, userID int64) error {
+	return s.rdb.Incr(ctx, predictionsKey(userID)).Err()
+}
+
+func (s *RedisStore) RefundQuota(ctx context.Context, userID int64, cost int64) error {
+	return s.rdb.IncrBy(ctx, quotaKey(userID), cost).Err()
+}
+
+func (s *RedisStore) ReservePart(ctx context.Context, userID int64, cost int64) error {
+	keys := []string{quotaKey(userID), creditKey(userID)}
+	units, err := reservePartScript.Run(ctx, s.rdb, keys, cost, objects.QuotaUnit).Int64()
+	if err != nil {
+		return err
+	}
+	if units < 0 {
+		return ErrQuotaExhausted
+	}
+	return nil
+}
+
+func (s *RedisStore) RefundPart(ctx context.Context, userID int64, cost int64) error {
+	keys := []string{quotaKey(userID), creditKey(userID)}
+	return refundPartScript.Run(ctx, s.rdb, keys, cost, objects.QuotaUnit).Err()
+}
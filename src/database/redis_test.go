@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisMigrate(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+	ctx := context.Background()
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+	if err := rdb.FlushDB(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	// A profile stored under the username, a profile saved before the indexes and the colon-free indexes.
+	rdb.Set(ctx, "alice", `{"chat_id":1,"username":"alice","name":"Alice","quote":5,"predictions":2}`, 0)
+	rdb.Set(ctx, profileKey(2), `{"user_id":2,"chat_id":2,"subscription":{"hour":9,"timezone":"UTC"}}`, 0)
+	rdb.ZAdd(ctx, broadcastsKey, &redis.Z{Score: 1, Member: "b1"})
+	rdb.ZAdd(ctx, pendingPaymentsKey, &redis.Z{Score: 1, Member: "charge"})
+
+	store, err := NewRedisStore(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if version, err := rdb.Get(ctx, schemaVersionKey).Int(); err != nil || version != len(redisMigrations) {
+		t.Fatalf("schema version = %d, %v, want %d", version, err, len(redisMigrations))
+	}
+	profile, err := store.GetProfile(ctx, 1)
+	if err != nil || profile.Name != "Alice" {
+		t.Fatalf("migrated profile = %+v, %v", profile, err)
+	}
+	if n, _ := rdb.Exists(ctx, "alice").Result(); n != 0 {
+		t.Error("legacy key is not deleted")
+	}
+	if quota, err := store.GetQuota(ctx, 1); err != nil || quota.Available != 5 || quota.Predictions != 2 {
+		t.Errorf("migrated quota = %+v, %v", quota, err)
+	}
+	if ids, _ := rdb.ZRange(ctx, profilesKey, 0, -1).Result(); len(ids) != 2 {
+		t.Errorf("profile list = %v", ids)
+	}
+	if _, err := rdb.ZScore(ctx, forecastsKey, "2").Result(); err != nil {
+		t.Errorf("subscription is not indexed: %v", err)
+	}
+	for _, key := range []string{broadcastsKey, pendingPaymentsKey} {
+		if n, _ := rdb.ZCard(ctx, key).Result(); n != 1 {
+			t.Errorf("index %s is changed", key)
+		}
+	}
+
+	// The applied migrations are not run again.
+	rdb.Set(ctx, "bob", `{"chat_id":3,"username":"bob"}`, 0)
+	again, err := NewRedisStore(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer again.Close()
+	if n, _ := rdb.Exists(ctx, "bob").Result(); n != 1 {
+		t.Error("migrations are run again")
+	}
+}
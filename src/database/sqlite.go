@@ -0,0 +1,264 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"tgbot-numerologist/objects"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS profiles (
+	user_id  INTEGER PRIMARY KEY,
+	username TEXT NOT NULL DEFAULT '',
+	data     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS profiles_username ON profiles(username);
+CREATE TABLE IF NOT EXISTS quotas (
+	user_id     INTEGER PRIMARY KEY,
+	available   INTEGER NOT NULL DEFAULT 0,
+	predictions INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS quota_credits (
+	user_id INTEGER PRIMARY KEY,
+	credit  INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS payments (
+	charge_id          TEXT PRIMARY KEY,
+	provider_charge_id TEXT NOT NULL,
+	user_id            INTEGER NOT NULL,
+	package_id         TEXT NOT NULL,
+	quota              INTEGER NOT NULL,
+	amount             INTEGER NOT NULL,
+	currency           TEXT NOT NULL,
+	created_at         TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS payments_user_id ON payments(user_id, created_at);
+CREATE TABLE IF NOT EXISTS pending_payments (
+	charge_id TEXT PRIMARY KEY
+);
+CREATE TABLE IF NOT EXISTS predictions (
+	id           TEXT NOT NULL,
+	user_id      INTEGER NOT NULL,
+	created_at   TIMESTAMP NOT NULL,
+	type         TEXT NOT NULL,
+	profile_hash TEXT NOT NULL,
+	model        TEXT NOT NULL,
+	text         TEXT NOT NULL,
+	PRIMARY KEY (user_id, id)
+);
+CREATE INDEX IF NOT EXISTS predictions_created_at ON predictions(user_id, created_at);
+CREATE TABLE IF NOT EXISTS conversations (
+	user_id    INTEGER PRIMARY KEY,
+	data       TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS audit (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at TIMESTAMP NOT NULL,
+	admin_id   INTEGER NOT NULL,
+	username   TEXT NOT NULL,
+	command    TEXT NOT NULL,
+	args       TEXT NOT NULL,
+	allowed    BOOLEAN NOT NULL
+);
+CREATE TABLE IF NOT EXISTS dead_letters (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at TIMESTAMP NOT NULL,
+	chat_id    INTEGER NOT NULL,
+	method     TEXT NOT NULL,
+	text       TEXT NOT NULL,
+	parse_mode TEXT NOT NULL,
+	error      TEXT NOT NULL,
+	attempts   INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS forecasts (
+	user_id INTEGER PRIMARY KEY,
+	due_at  INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS forecasts_due_at ON forecasts(due_at);
+CREATE TABLE IF NOT EXISTS broadcasts (
+	id         TEXT PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	data       TEXT NOT NULL
+);
+`
+
+// sqliteMigrations are run on every start and must be idempotent.
+var sqliteMigrations = []string{
+	// Quota counters used to be stored in the profile.
+	`INSERT OR IGNORE INTO quotas (user_id, available, predictions)
+		SELECT user_id, json_extract(data, '$.quote'), COALESCE(json_extract(data, '$.predictions'), 0)
+		FROM profiles WHERE json_extract(data, '$.quote') IS NOT NULL`,
+}
+
+// SQLiteStore keeps everything in a single SQLite file, suitable for single host deployments.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows only one writer, a single connection serializes transactions without busy errors.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, migration := range sqliteMigrations {
+		if _, err := db.Exec(migration); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if err := indexSubscriptions(context.Background(), db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func getProfile(ctx context.Context, q queryer, userID int64) (*objects.Profile, error) {
+	var data []byte
+	err := q.QueryRowContext(ctx, `SELECT data FROM profiles WHERE user_id = ?`, userID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeProfile(data)
+}
+
+func saveProfile(ctx context.Context, q queryer, profile *objects.Profile) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	username := normalizeUsername(profile.Username)
+	if username != "" {
+		// The username may have been taken over from another user.
+		_, err = q.ExecContext(ctx, `UPDATE profiles SET username = '' WHERE username = ? AND user_id != ?`, username, profile.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO profiles (user_id, username, data) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET username = excluded.username, data = excluded.data`,
+		profile.UserID, username, data)
+	if err != nil {
+		return err
+	}
+	return indexForecast(ctx, q, profile)
+}
+
+func (s *SQLiteStore) GetProfile(ctx context.Context, userID int64) (*objects.Profile, error) {
+	return getProfile(ctx, s.db, userID)
+}
+
+func (s *SQLiteStore) SaveProfile(ctx context.Context, profile *objects.Profile) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		return saveProfile(ctx, tx, profile)
+	})
+}
+
+func (s *SQLiteStore) ProfileExists(ctx context.Context, userID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM profiles WHERE user_id = ?)`, userID).Scan(&exists)
+	return exists, err
+}
+
+func (s *SQLiteStore) DeleteProfile(ctx context.Context, userID int64) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM forecasts WHERE user_id = ?`, userID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM profiles WHERE user_id = ?`, userID)
+		return err
+	})
+}
+
+func (s *SQLiteStore) ListProfiles(ctx context.Context, afterID int64, limit int) ([]*objects.Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM profiles WHERE user_id > ? ORDER BY user_id LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []*objects.Profile
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		profile, err := decodeProfile(data)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
+// withTx runs fn in a transaction which is committed when fn succeeds.
+func (s *SQLiteStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) UpdateProfile(ctx context.Context, userID int64, update func(profile *objects.Profile) error) (*objects.Profile, error) {
+	var result *objects.Profile
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		profile, err := getProfile(ctx, tx, userID)
+		if errors.Is(err, ErrNotFound) {
+			profile, err = &objects.Profile{UserID: userID}, nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := update(profile); err != nil {
+			return err
+		}
+		profile.UserID = userID
+		result = profile
+		return saveProfile(ctx, tx, profile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) GetUserIDByUsername(ctx context.Context, username string) (int64, error) {
+	var userID int64
+	err := s.db.QueryRowContext(ctx, `SELECT user_id FROM profiles WHERE username = ?`, normalizeUsername(username)).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return userID, err
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *SQLiteStore) AddAuditRecord(ctx context.Context, record objects.AuditRecord) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO audit (created_at, admin_id, username, command, args, allowed)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			record.CreatedAt, record.AdminID, record.Username, record.Command, record.Args, record.Allowed)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM audit WHERE id <= (SELECT MAX(id) FROM audit) - ?`, MaxAuditRecords)
+		return err
+	})
+}
+
+func (s *SQLiteStore) ListAuditRecords(ctx context.Context, limit int) ([]objects.AuditRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT created_at, admin_id, username, command, args, allowed
+		FROM audit ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []objects.AuditRecord
+	for rows.Next() {
+		var r objects.AuditRecord
+		if err := rows.Scan(&r.CreatedAt, &r.AdminID, &r.Username, &r.Command, &r.Args, &r.Allowed); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *SQLiteStore) SaveBroadcast(ctx context.Context, broadcast objects.Broadcast) error {
+	data, err := json.Marshal(broadcast)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO broadcasts (id, created_at, data) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`,
+		broadcast.ID, broadcast.CreatedAt, data)
+	return err
+}
+
+func (s *SQLiteStore) GetBroadcast(ctx context.Context, id string) (*objects.Broadcast, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM broadcasts WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var broadcast objects.Broadcast
+	if err := json.Unmarshal(data, &broadcast); err != nil {
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+func (s *SQLiteStore) ListBroadcasts(ctx context.Context, limit int) ([]objects.Broadcast, error) {
+	return s.queryBroadcasts(ctx, `SELECT data FROM broadcasts ORDER BY created_at DESC LIMIT ?`, limit)
+}
+
+func (s *SQLiteStore) ListRunningBroadcasts(ctx context.Context) ([]objects.Broadcast, error) {
+	return s.queryBroadcasts(ctx, `
+		SELECT data FROM broadcasts WHERE json_extract(data, '$.status') = ? ORDER BY created_at`,
+		objects.BroadcastRunning)
+}
+
+func (s *SQLiteStore) queryBroadcasts(ctx context.Context, query string, args ...any) ([]objects.Broadcast, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var broadcasts []objects.Broadcast
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var broadcast objects.Broadcast
+		if err := json.Unmarshal(data, &broadcast); err != nil {
+			return nil, err
+		}
+		broadcasts = append(broadcasts, broadcast)
+	}
+	return broadcasts, rows.Err()
+}
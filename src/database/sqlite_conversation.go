@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *SQLiteStore) SaveConversation(ctx context.Context, conversation objects.Conversation, ttl time.Duration) error {
+	data, err := json.Marshal(conversation)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM conversations WHERE expires_at <= ?`, now); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO conversations (user_id, data, expires_at) VALUES (?, ?, ?)
+			ON CONFLICT(user_id) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at`,
+			conversation.UserID, data, now.Add(ttl))
+		return err
+	})
+}
+
+func (s *SQLiteStore) GetConversation(ctx context.Context, userID int64) (*objects.Conversation, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM conversations WHERE user_id = ? AND expires_at > ?`,
+		userID, time.Now()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conversation objects.Conversation
+	if err := json.Unmarshal(data, &conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (s *SQLiteStore) DeleteConversation(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM conversations WHERE user_id = ?`, userID)
+	return err
+}
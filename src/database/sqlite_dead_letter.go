@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *SQLiteStore) AddDeadLetter(ctx context.Context, letter objects.DeadLetter) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO dead_letters (created_at, chat_id, method, text, parse_mode, error, attempts)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			letter.CreatedAt, letter.ChatID, letter.Method, letter.Text, letter.ParseMode, letter.Error, letter.Attempts)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM dead_letters WHERE id <= (SELECT MAX(id) FROM dead_letters) - ?`, MaxDeadLetters)
+		return err
+	})
+}
+
+func (s *SQLiteStore) ListDeadLetters(ctx context.Context, limit int) ([]objects.DeadLetter, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT created_at, chat_id, method, text, parse_mode, error, attempts
+		FROM dead_letters ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var letters []objects.DeadLetter
+	for rows.Next() {
+		var l objects.DeadLetter
+		if err := rows.Scan(&l.CreatedAt, &l.ChatID, &l.Method, &l.Text, &l.ParseMode, &l.Error, &l.Attempts); err != nil {
+			return nil, err
+		}
+		letters = append(letters, l)
+	}
+	return letters, rows.Err()
+}
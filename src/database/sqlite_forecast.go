@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tgbot-numerologist/objects"
+)
+
+// indexForecast updates the forecast index with the saved profile.
+func indexForecast(ctx context.Context, q queryer, profile *objects.Profile) error {
+	due, ok := forecastDue(profile)
+	if !ok {
+		_, err := q.ExecContext(ctx, `DELETE FROM forecasts WHERE user_id = ?`, profile.UserID)
+		return err
+	}
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO forecasts (user_id, due_at) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET due_at = excluded.due_at`, profile.UserID, due.Unix())
+	return err
+}
+
+// indexSubscriptions adds the subscriptions saved before the forecast index existed.
+func indexSubscriptions(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT data FROM profiles
+		WHERE json_extract(data, '$.subscription') IS NOT NULL AND user_id NOT IN (SELECT user_id FROM forecasts)`)
+	if err != nil {
+		return err
+	}
+	var profiles []*objects.Profile
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return err
+		}
+		profile, err := decodeProfile(data)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		profiles = append(profiles, profile)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		if err := indexForecast(ctx, db, profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DueForecasts(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id FROM forecasts WHERE due_at <= ? ORDER BY due_at LIMIT ?`, now.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
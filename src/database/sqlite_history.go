@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"tgbot-numerologist/objects"
+)
+
+const predictionColumns = `id, user_id, created_at, type, profile_hash, model, text`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPrediction(row scanner) (objects.Prediction, error) {
+	var p objects.Prediction
+	err := row.Scan(&p.ID, &p.UserID, &p.CreatedAt, &p.Type, &p.ProfileHash, &p.Model, &p.Text)
+	return p, err
+}
+
+func (s *SQLiteStore) AddPrediction(ctx context.Context, p objects.Prediction) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO predictions (`+predictionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.UserID, p.CreatedAt, p.Type, p.ProfileHash, p.Model, p.Text)
+	return err
+}
+
+func (s *SQLiteStore) ListPredictions(ctx context.Context, userID int64, offset, limit int) ([]objects.Prediction, int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM predictions WHERE user_id = ?`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+predictionColumns+` FROM predictions WHERE user_id = ?
+		ORDER BY created_at DESC LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var predictions []objects.Prediction
+	for rows.Next() {
+		prediction, err := scanPrediction(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		predictions = append(predictions, prediction)
+	}
+	return predictions, total, rows.Err()
+}
+
+func (s *SQLiteStore) GetPrediction(ctx context.Context, userID int64, id string) (*objects.Prediction, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+predictionColumns+` FROM predictions WHERE user_id = ? AND id = ?`, userID, id)
+	prediction, err := scanPrediction(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prediction, nil
+}
+
+func (s *SQLiteStore) DeletePrediction(ctx context.Context, userID int64, id string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM predictions WHERE user_id = ? AND id = ?`, userID, id)
+	return err
+}
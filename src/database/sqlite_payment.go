@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"tgbot-numerologist/objects"
+)
+
+// insertPayment adds the payment to the ledger and reports whether it was not recorded before.
+func insertPayment(ctx context.Context, q queryer, payment objects.Payment) (bool, error) {
+	res, err := q.ExecContext(ctx, `
+		INSERT OR IGNORE INTO payments
+			(charge_id, provider_charge_id, user_id, package_id, quota, amount, currency, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		payment.ChargeID, payment.ProviderChargeID, payment.UserID, payment.PackageID,
+		payment.Quota, payment.Amount, payment.Currency, payment.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *SQLiteStore) RecordPayment(ctx context.Context, payment objects.Payment) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		inserted, err := insertPayment(ctx, tx, payment)
+		if err != nil || !inserted {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO pending_payments (charge_id) VALUES (?)`, payment.ChargeID)
+		return err
+	})
+}
+
+func (s *SQLiteStore) CreditPayment(ctx context.Context, payment objects.Payment) (bool, error) {
+	credited := false
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		inserted, err := insertPayment(ctx, tx, payment)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			// The recorded payment is credited once, when it leaves the pending ones.
+			res, err := tx.ExecContext(ctx, `DELETE FROM pending_payments WHERE charge_id = ?`, payment.ChargeID)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil || n == 0 {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO quotas (user_id, available) VALUES (?, ?)
+			ON CONFLICT(user_id) DO UPDATE SET available = available + excluded.available`,
+			payment.UserID, payment.Quota)
+		credited = err == nil
+		return err
+	})
+	return credited, err
+}
+
+func (s *SQLiteStore) PendingPayments(ctx context.Context, limit int) ([]objects.Payment, error) {
+	return s.queryPayments(ctx, `
+		SELECT charge_id, provider_charge_id, user_id, package_id, quota, amount, currency, created_at
+		FROM payments JOIN pending_payments USING (charge_id) ORDER BY created_at LIMIT ?`, limit)
+}
+
+func (s *SQLiteStore) ListPayments(ctx context.Context, userID int64) ([]objects.Payment, error) {
+	return s.queryPayments(ctx, `
+		SELECT charge_id, provider_charge_id, user_id, package_id, quota, amount, currency, created_at
+		FROM payments WHERE user_id = ? ORDER BY created_at DESC`, userID)
+}
+
+func (s *SQLiteStore) queryPayments(ctx context.Context, query string, args ...any) ([]objects.Payment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []objects.Payment
+	for rows.Next() {
+		var p objects.Payment
+		err := rows.Scan(&p.ChargeID, &p.ProviderChargeID, &p.UserID, &p.PackageID, &p.Quota, &p.Amount, &p.Currency, &p.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
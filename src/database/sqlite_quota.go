@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"tgbot-numerologist/objects"
+)
+
+func (s *SQLiteStore) InitQuota(ctx context.Context, userID int64, available int64) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO quotas (user_id, available) VALUES (?, ?)`, userID, available)
+	return err
+}
+
+func (s *SQLiteStore) GetQuota(ctx context.Context, userID int64) (Quota, error) {
+	var quota Quota
+	err := s.db.QueryRowContext(ctx, `SELECT available, predictions FROM quotas WHERE user_id = ?`, userID).
+		Scan(&quota.Available, &quota.Predictions)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Quota{}, err
+	}
+	quota.Credit, err = getCredit(ctx, s.db, userID)
+	return quota, err
+}
+
+func (s *SQLiteStore) AddQuota(ctx context.Context, userID int64, n int64) (int64, error) {
+	var available int64
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO quotas (user_id, available) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET available = available + excluded.available
+		RETURNING available`, userID, n).Scan(&available)
+	return available, err
+}
+
+func (s *SQLiteStore) ReserveQuota(ctx context.Context, userID int64, cost int64) error {
+	return reserveQuota(ctx, s.db, userID, cost)
+}
+
+func reserveQuota(ctx context.Context, q queryer, userID int64, cost int64) error {
+	res, err := q.ExecContext(ctx, `UPDATE quotas SET available = available - ? WHERE user_id = ? AND available >= ?`, cost, userID, cost)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrQuotaExhausted
+	}
+	return nil
+}
+
+func (s *SQLiteStore) CommitQuota(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO quotas (user_id, predictions) VALUES (?, 1)
+		ON CONFLICT(user_id) DO UPDATE SET predictions = predictions + 1`, userID)
+	return err
+}
+
+func (s *SQLiteStore) RefundQuota(ctx context.Context, userID int64, cost int64) error {
+	_, err := s.AddQuota(ctx, userID, cost)
+	return err
+}
+
+func getCredit(ctx context.Context, q queryer, userID int64) (int64, error) {
+	var credit int64
+	err := q.QueryRowContext(ctx, `SELECT credit FROM quota_credits WHERE user_id = ?`, userID).Scan(&credit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return credit, err
+}
+
+func setCredit(ctx context.Context, q queryer, userID int64, credit int64) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO quota_credits (user_id, credit) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET credit = excluded.credit`, userID, credit)
+	return err
+}
+
+func (s *SQLiteStore) ReservePart(ctx context.Context, userID int64, cost int64) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		credit, err := getCredit(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		units := missingUnits(credit, cost)
+		if units > 0 {
+			if err := reserveQuota(ctx, tx, userID, units); err != nil {
+				return err
+			}
+		}
+		return setCredit(ctx, tx, userID, credit+units*objects.QuotaUnit-cost)
+	})
+}
+
+func (s *SQLiteStore) RefundPart(ctx context.Context, userID int64, cost int64) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		credit, err := getCredit(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		credit += cost
+		if units := credit / objects.QuotaUnit; units > 0 {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO quotas (user_id, available) VALUES (?, ?)
+				ON CONFLICT(user_id) DO UPDATE SET available = available + excluded.available`, userID, units)
+			if err != nil {
+				return err
+			}
+		}
+		return setCredit(ctx, tx, userID, credit%objects.QuotaUnit)
+	})
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"tgbot-numerologist/objects"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// ProfileStore persists user profiles keyed by Telegram user id.
+type ProfileStore interface {
+	// GetProfile returns ErrNotFound when the user has no profile.
+	GetProfile(ctx context.Context, userID int64) (*objects.Profile, error)
+	// SaveProfile creates or overwrites the profile and keeps the username index up to date.
+	// It is meant for new profiles, changes of a stored one go through UpdateProfile
+	// so the fields changed concurrently are not lost.
+	SaveProfile(ctx context.Context, profile *objects.Profile) error
+	ProfileExists(ctx context.Context, userID int64) (bool, error)
+	DeleteProfile(ctx context.Context, userID int64) error
+	// ListProfiles returns up to limit profiles with user id greater than afterID ordered by user id.
+	ListProfiles(ctx context.Context, afterID int64, limit int) ([]*objects.Profile, error)
+	// UpdateProfile atomically applies update to the stored profile and saves the result.
+	// Nothing is saved when update returns an error.
+	UpdateProfile(ctx context.Context, userID int64, update func(profile *objects.Profile) error) (*objects.Profile, error)
+	// GetUserIDByUsername returns ErrNotFound when no user has the username.
+	GetUserIDByUsername(ctx context.Context, username string) (int64, error)
+}
+
+// Store combines all the storages of the bot in one backend.
+type Store interface {
+	ProfileStore
+	QuotaStore
+	PaymentStore
+	HistoryStore
+	ConversationStore
+	AuditStore
+	BroadcastStore
+	DeadLetterStore
+	ForecastStore
+	Close() error
+}
+
+type Config struct {
+	// Backend is one of "redis", "sqlite" or "memory".
+	Backend    string
+	RedisAddr  string
+	SQLitePath string
+}
+
+func Open(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "redis", "":
+		return NewRedisStore(cfg.RedisAddr)
+	case "sqlite":
+		return NewSQLiteStore(cfg.SQLitePath)
+	case "memory":
+		return NewMemoryStore(), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+}
+
+// normalizeUsername makes username lookups case insensitive, as Telegram usernames are.
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimPrefix(username, "@"))
+}
+
+func GetChatId(ctx context.Context, store ProfileStore, username string) (int64, error) {
+	userID, err := store.GetUserIDByUsername(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	profile, err := store.GetProfile(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return profile.ChatID, nil
+}
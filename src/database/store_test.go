@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+
+	"tgbot-numerologist/objects"
+	"tgbot-numerologist/utils"
+)
+
+func TestMain(m *testing.M) {
+	utils.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// stores returns an empty store of every backend. Redis is tested only when TEST_REDIS_ADDR
+// points to a disposable server, its database is flushed.
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	sqlite, err := NewSQLiteStore(t.TempDir() + "/bot.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	result := map[string]Store{"memory": NewMemoryStore(), "sqlite": sqlite}
+	if addr := os.Getenv("TEST_REDIS_ADDR"); addr != "" {
+		redis, err := NewRedisStore(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := redis.rdb.FlushDB(context.Background()).Err(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { redis.Close() })
+		result["redis"] = redis
+	}
+	return result
+}
+
+func TestProfiles(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := store.GetProfile(ctx, 1); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetProfile of a new user: %v", err)
+			}
+			for _, id := range []int64{3, 1, 2} {
+				profile := objects.NewProfile(id, "user"+strconv.FormatInt(id, 10), id)
+				if err := store.SaveProfile(ctx, &profile); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			profile, err := store.GetProfile(ctx, 1)
+			if err != nil || profile.Username != "user1" || profile.ChatID != 1 {
+				t.Fatalf("GetProfile = %+v, %v", profile, err)
+			}
+			if exists, err := store.ProfileExists(ctx, 2); err != nil || !exists {
+				t.Errorf("ProfileExists(2) = %v, %v", exists, err)
+			}
+
+			var ids []int64
+			for afterID := int64(0); ; {
+				page, err := store.ListProfiles(ctx, afterID, 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, p := range page {
+					ids = append(ids, p.UserID)
+					afterID = p.UserID
+				}
+				if len(page) < 2 {
+					break
+				}
+			}
+			if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+				t.Errorf("ListProfiles listed %v", ids)
+			}
+
+			if err := store.DeleteProfile(ctx, 2); err != nil {
+				t.Fatal(err)
+			}
+			if exists, _ := store.ProfileExists(ctx, 2); exists {
+				t.Error("deleted profile exists")
+			}
+			if _, err := store.GetUserIDByUsername(ctx, "user2"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("username of the deleted profile: %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			profile := objects.NewProfile(1, "anna", 1)
+			if err := store.SaveProfile(ctx, &profile); err != nil {
+				t.Fatal(err)
+			}
+
+			updated, err := store.UpdateProfile(ctx, 1, func(p *objects.Profile) error {
+				p.Name = "Анна"
+				return nil
+			})
+			if err != nil || updated.Name != "Анна" || updated.Username != "anna" {
+				t.Fatalf("UpdateProfile = %+v, %v", updated, err)
+			}
+
+			failed := errors.New("failed")
+			_, err = store.UpdateProfile(ctx, 1, func(p *objects.Profile) error {
+				p.Name = "lost"
+				return failed
+			})
+			if !errors.Is(err, failed) {
+				t.Errorf("UpdateProfile error = %v", err)
+			}
+			if stored, _ := store.GetProfile(ctx, 1); stored.Name != "Анна" {
+				t.Errorf("failed update is saved: %q", stored.Name)
+			}
+
+			// The user id can not be changed by the update.
+			updated, err = store.UpdateProfile(ctx, 1, func(p *objects.Profile) error {
+				p.UserID = 5
+				return nil
+			})
+			if err != nil || updated.UserID != 1 {
+				t.Errorf("UpdateProfile changed the user id to %d: %v", updated.UserID, err)
+			}
+		})
+	}
+}
+
+func TestUsernames(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			first := objects.NewProfile(1, "Anna", 1)
+			if err := store.SaveProfile(ctx, &first); err != nil {
+				t.Fatal(err)
+			}
+			for _, username := range []string{"Anna", "anna", "@ANNA"} {
+				if id, err := store.GetUserIDByUsername(ctx, username); err != nil || id != 1 {
+					t.Errorf("GetUserIDByUsername(%s) = %d, %v", username, id, err)
+				}
+			}
+
+			// The first user renames and the second one takes over the old username.
+			if _, err := store.UpdateProfile(ctx, 1, func(p *objects.Profile) error {
+				p.Username = "anna_new"
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.GetUserIDByUsername(ctx, "anna"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("old username is still found: %v", err)
+			}
+			second := objects.NewProfile(2, "anna", 2)
+			if err := store.SaveProfile(ctx, &second); err != nil {
+				t.Fatal(err)
+			}
+			if id, err := store.GetUserIDByUsername(ctx, "anna"); err != nil || id != 2 {
+				t.Errorf("taken over username belongs to %d: %v", id, err)
+			}
+			if chatID, err := GetChatId(ctx, store, "anna_new"); err != nil || chatID != 1 {
+				t.Errorf("GetChatId(anna_new) = %d, %v", chatID, err)
+			}
+		})
+	}
+}
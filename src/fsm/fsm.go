@@ -0,0 +1,86 @@
+package fsm
+
+import "fmt"
+
+// State is a step of a dialog with the user. E is the environment the hooks work with,
+// e.g. the bot, the chat and the profile of the user.
+type State[E any] struct {
+	// Prompt returns the question asked when the state is entered.
+	Prompt func(env E) string
+	// Apply validates the answer and saves it. An InputError is shown to the user
+	// and the question is repeated, the state does not change.
+	Apply func(env E, text string) error
+	// Next is the state entered after a valid answer or a skip, empty finishes the dialog.
+	Next string
+	// Skippable states can be left without an answer, e.g. optional fields of the onboarding wizard.
+	Skippable bool
+	// Done is called when the dialog finishes in this state.
+	Done func(env E)
+	// Cancel is called when the user stops the dialog in this state.
+	Cancel func(env E)
+}
+
+// InputError is returned by Apply for a wrong answer, Message is the message ID shown to the user.
+type InputError struct {
+	Message string
+	Args    []any
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("wrong input: %s", e.Message)
+}
+
+func NewInputError(message string, args ...any) *InputError {
+	return &InputError{Message: message, Args: args}
+}
+
+// Machine is the set of the dialog states keyed by ID.
+type Machine[E any] struct {
+	states map[string]State[E]
+}
+
+func New[E any]() *Machine[E] {
+	return &Machine[E]{states: make(map[string]State[E])}
+}
+
+// Add registers the state, it panics on a duplicate ID or a Next state which is not registered yet,
+// so the states are added from the last to the first.
+func (m *Machine[E]) Add(id string, state State[E]) {
+	if _, ok := m.states[id]; ok {
+		panic(fmt.Sprintf("fsm: duplicate state %q", id))
+	}
+	if state.Next != "" {
+		if _, ok := m.states[state.Next]; !ok {
+			panic(fmt.Sprintf("fsm: state %q goes to unknown state %q", id, state.Next))
+		}
+	}
+	m.states[id] = state
+}
+
+func (m *Machine[E]) Get(id string) (State[E], bool) {
+	state, ok := m.states[id]
+	return state, ok
+}
+
+// Answer applies the answer in the state and returns the next state, empty when the dialog is finished.
+func (m *Machine[E]) Answer(id string, env E, text string) (string, error) {
+	state, ok := m.states[id]
+	if !ok {
+		return "", fmt.Errorf("fsm: unknown state %q", id)
+	}
+	if state.Apply != nil {
+		if err := state.Apply(env, text); err != nil {
+			return id, err
+		}
+	}
+	return state.Next, nil
+}
+
+// Skip leaves the skippable state without an answer and returns the next state.
+func (m *Machine[E]) Skip(id string) (string, bool) {
+	state, ok := m.states[id]
+	if !ok || !state.Skippable {
+		return id, false
+	}
+	return state.Next, true
+}
@@ -0,0 +1,122 @@
+package fsm
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+type form struct {
+	name string
+	age  int
+}
+
+// wizard asks the name, then the optional age and finishes.
+func wizard() *Machine[*form] {
+	m := New[*form]()
+	m.Add("age", State[*form]{
+		Apply: func(f *form, text string) error {
+			age, err := strconv.Atoi(text)
+			if err != nil || age <= 0 {
+				return NewInputError("wrong_age", text)
+			}
+			f.age = age
+			return nil
+		},
+		Skippable: true,
+	})
+	m.Add("name", State[*form]{
+		Apply: func(f *form, text string) error {
+			if text == "" {
+				return NewInputError("empty_name")
+			}
+			f.name = text
+			return nil
+		},
+		Next: "age",
+	})
+	m.Add("confirm", State[*form]{Next: "name"})
+	return m
+}
+
+func TestAnswer(t *testing.T) {
+	tests := []struct {
+		state, text string
+		next        string
+		inputError  string
+		want        form
+	}{
+		{"name", "Анна", "age", "", form{name: "Анна"}},
+		{"name", "", "name", "empty_name", form{}},
+		{"age", "30", "", "", form{age: 30}},
+		{"age", "thirty", "age", "wrong_age", form{}},
+		{"age", "-1", "age", "wrong_age", form{}},
+		{"confirm", "anything", "name", "", form{}},
+	}
+	for _, tt := range tests {
+		var f form
+		next, err := wizard().Answer(tt.state, &f, tt.text)
+		if next != tt.next {
+			t.Errorf("Answer(%s, %q) went to %q, want %q", tt.state, tt.text, next, tt.next)
+		}
+		var inputErr *InputError
+		switch {
+		case tt.inputError == "" && err != nil:
+			t.Errorf("Answer(%s, %q) error = %v", tt.state, tt.text, err)
+		case tt.inputError != "" && (!errors.As(err, &inputErr) || inputErr.Message != tt.inputError):
+			t.Errorf("Answer(%s, %q) error = %v, want %s", tt.state, tt.text, err, tt.inputError)
+		}
+		if f != tt.want {
+			t.Errorf("Answer(%s, %q) saved %+v, want %+v", tt.state, tt.text, f, tt.want)
+		}
+	}
+}
+
+func TestAnswerUnknownState(t *testing.T) {
+	next, err := wizard().Answer("missing", &form{}, "text")
+	if err == nil || next != "" {
+		t.Errorf("Answer(missing) = %q, %v", next, err)
+	}
+	var inputErr *InputError
+	if errors.As(err, &inputErr) {
+		t.Errorf("unknown state is reported as a wrong input")
+	}
+}
+
+func TestSkip(t *testing.T) {
+	tests := []struct {
+		state string
+		next  string
+		ok    bool
+	}{
+		{"age", "", true},
+		{"name", "name", false},
+		{"missing", "missing", false},
+	}
+	for _, tt := range tests {
+		next, ok := wizard().Skip(tt.state)
+		if next != tt.next || ok != tt.ok {
+			t.Errorf("Skip(%s) = %q, %v, want %q, %v", tt.state, next, ok, tt.next, tt.ok)
+		}
+	}
+}
+
+func TestAddPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(m *Machine[*form])
+	}{
+		{"duplicate", func(m *Machine[*form]) { m.Add("name", State[*form]{}) }},
+		{"unknown next", func(m *Machine[*form]) { m.Add("start", State[*form]{Next: "missing"}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Add did not panic")
+				}
+			}()
+			tt.add(wizard())
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package i18n
+
+var english = map[string]string{
+	"intro":    "I will help you understand your true self through numbers and more:\n1. /profile - fill in your profile\n2. /predictions - see the available predictions\n3. /help - learn what else the bot can do\nFill in all the fields to get a more accurate forecast",
+	"help":     "Available commands:\n/profile - your profile for predictions\n/predictions - get a prediction from the numerologist bot. Reply to the prediction message to ask a question about it\n/history - history of your predictions\n/subscribe - daily forecast at the chosen time\n/unsubscribe - turn off the daily forecast\n/share - link your partner can use to check compatibility with you\n/payment - view and top up your quota\n/reset - clear the profile. The quota is kept\n/language - bot language\n/feedback - leave feedback\n/intro - welcome message\n/stop - cancel editing the profile\n/help - list of commands",
+	"feedback": "You can leave feedback about the bot using the link below:\nhttps://docs.google.com/forms/d/1Txnv0dsKpI5Lcf0bI2AH3Mw1Ly0RP5okOEA-OlYHw6U/edit",
+
+	ErrUnknownCommand:  "Unknown command",
+	ErrGotSomeProblems: "Something went wrong, please try again later",
+	ErrFillRequired:    "Please fill in all the required fields of your profile",
+	ErrContentFiltered: "The model declined to answer this request, please try to rephrase it",
+	ErrContextTooLong:  "The conversation became too long for the model, please order a new prediction",
+
+	"predictions#one":   "%d prediction",
+	"predictions#other": "%d predictions",
+
+	"profile.title":            "*Your profile*:\n",
+	"profile.required":         "_(required)_",
+	"profile.name":             "Name",
+	"profile.surname":          "Surname",
+	"profile.birthdate":        "Date of birth",
+	"profile.birthtime":        "Time of birth",
+	"profile.birthplace":       "Place of birth",
+	"profile.workplace":        "Workplace",
+	"profile.studyplace":       "Place of study",
+	"profile.hobby":            "Hobby",
+	"profile.bio":              "Biography",
+	"profile.edit_name":        "Edit name",
+	"profile.edit_surname":     "Edit surname",
+	"profile.edit_birthdate":   "Edit date of birth",
+	"profile.edit_birthtime":   "Edit time of birth",
+	"profile.edit_birthplace":  "Edit place of birth",
+	"profile.edit_workplace":   "Edit workplace",
+	"profile.edit_studyplace":  "Edit place of study",
+	"profile.edit_hobby":       "Edit hobby",
+	"profile.edit_bio":         "Edit biography",
+	"profile.enter_name":       "Enter your name:",
+	"profile.enter_surname":    "Enter your surname:",
+	"profile.enter_birthdate":  "Enter your date of birth, e.g. 05.03.1990 or March 5, 1990, or pick it in the calendar:",
+	"profile.enter_birthtime":  "Enter the local time of your birth, e.g. 09:30:",
+	"profile.enter_birthplace": "Enter the city of your birth, e.g. Moscow or Brest, Belarus:",
+	"profile.enter_bio":        "Enter your biography:",
+	"profile.enter_workplace":  "Enter your workplace:",
+	"profile.enter_studyplace": "Enter your place of study:",
+	"profile.enter_hobby":      "Describe your hobby:",
+	"profile.updated":          "Your profile is updated",
+	"profile.reset":            "Your profile data is cleared",
+	"profile.use_buttons":      "Use the buttons of the profile menu to edit a field",
+	"profile.wrong_date":       "Couldn't read the date, write it as 05.03.1990 or March 5, 1990 or pick it in the calendar",
+	"profile.ambiguous_date":   "Did you mean %s or %s? Write the month as a word, e.g. March 5, 1990, or pick the date in the calendar",
+	"profile.wrong_time":       "Couldn't read the time, write it as 09:30",
+	"profile.unknown_place":    "I don't know this city. Enter the nearest large city, or the place and its time zone after a comma, e.g. Smallville, UTC+3 or Smallville, Europe/Moscow",
+	"profile.ambiguous_place":  "There are several cities with this name, add the country after a comma:\n%s",
+	"calendar.open":            "📅 Pick in the calendar",
+	"calendar.months":          "Jan Feb Mar Apr May Jun Jul Aug Sep Oct Nov Dec",
+	"calendar.weekdays":        "Mo Tu We Th Fr Sa Su",
+	"calendar.expired":         "The date is not asked now",
+
+	"validation.empty":   "The value can't be empty",
+	"validation.min":     "The value is too short, the minimum length is %d",
+	"validation.max":     "The value is too long, the maximum length is %d",
+	"validation.script":  "Use letters of a single alphabet, spaces and hyphens only",
+	"validation.text":    "The text contains invalid characters",
+	"validation.future":  "The date can't be in the future",
+	"validation.min_age": "The minimum age is %d",
+	"validation.max_age": "The maximum age is %d",
+
+	"edit.stop_hint":   "Send /stop to cancel",
+	"edit.waiting":     "Waiting for your input...",
+	"edit.not_editing": "You are not editing the profile",
+	"edit.cancelled":   "Input cancelled:\n",
+	"dialog.cancelled": "Input cancelled",
+
+	"wizard.start":       "Let's fill in your profile. The required fields go first, the rest can be skipped",
+	"wizard.skip_hint":   "Send /skip to skip this step",
+	"wizard.cannot_skip": "This step can't be skipped",
+	"wizard.done":        "Your profile is filled in! Now you can get a prediction in /predictions\n\n",
+	"wizard.cancelled":   "You can fill in your profile later in /profile",
+
+	"payment.status":              "Predictions left: %d\nPredictions made: %d\nYou can buy more predictions for Telegram Stars with the buttons below",
+	"payment.package":             "%s — %d ⭐",
+	"payment.history_button":      "Purchase history",
+	"payment.package_unavailable": "The package is no longer available",
+	"payment.invoice_description": "Quota top-up by %s",
+	"payment.no_payments":         "You have no purchases yet",
+	"payment.history":             "Purchase history:\n",
+	"payment.history_row":         "%s: +%s for %d %s\n",
+	"payment.pre_checkout_failed": "This package is no longer available, open /payment and choose a package again",
+	"payment.thanks":              "Thank you for your purchase! Your quota is increased by %s",
+	"payment.pending":             "Your payment is recorded, the predictions will be added to your quota within a few minutes",
+	"payment.not_recorded":        "We could not record your payment because of a technical problem. Please send this code to the support via /feedback, the predictions will be added by hand: %s",
+
+	"history.empty":     "You have no saved predictions yet. Get the first one with /predictions",
+	"history.page":      "Prediction history (page %d of %d):\n\n",
+	"history.show":      "Show %d",
+	"history.delete":    "Delete %d",
+	"history.back":      "◀️ Back",
+	"history.forward":   "Next ▶️",
+	"history.not_found": "Prediction not found",
+	"history.deleted":   "Prediction deleted",
+
+	"predictions.menu":         "Choose a prediction. The number in brackets is how many predictions of your quota it costs:",
+	"predictions.unavailable":  "This prediction is no longer available",
+	"predictions.wrong_input":  "Could not understand the answer, please try again",
+	"predictions.missing_data": "Your profile lacks the data for this prediction, check it in /profile",
+	"predictions.no_quota":     "Not enough quota: this prediction costs %s. Top it up in /payment",
+	"predictions.waiting":      "Waiting for the numerology forecast...",
+
+	"prediction.general":       "General forecast",
+	"prediction.daily":         "Forecast for today",
+	"prediction.personal_year": "Personal year and month",
+	"prediction.compatibility": "Compatibility",
+	"prediction.career":        "Career and calling",
+	"prediction.name":          "Name analysis",
+	"prediction.house":         "House or apartment number",
+	"prediction.house.input":   "Enter the house or apartment number, e.g. 12B:",
+	"prediction.lucky_dates":   "Lucky dates",
+
+	"conversation.expired":   "The conversation about this prediction is over. Get a new prediction in /predictions and reply to it to ask a question",
+	"conversation.text_only": "Ask your question as text in reply to the prediction message",
+	"conversation.no_quota":  "Not enough quota for a question. Top it up in /payment",
+	"conversation.waiting":   "Thinking about your question...",
+
+	"partners.menu":       "Choose a partner to check compatibility with or add a new one.\nA partner who uses the bot can send you their link from /share",
+	"partners.add":        "Add partner",
+	"partners.not_found":  "Partner not found",
+	"partners.not_shared": "This partner stopped sharing their profile. Ask them for a new link from /share or delete them from your partners",
+	"partners.deleted":    "Partner deleted",
+	"partners.enter_name": "Enter the partner's name and surname:",
+	"partners.enter_date": "Enter the partner's date of birth, e.g. 05.03.1990 or March 5, 1990, or pick it in the calendar:",
+	"partners.saved":      "Partner saved, they are available in the compatibility menu",
+	"partners.added":      "%s is added to your partners",
+	"share.link":          "Send this link to your partner. By opening it they can check compatibility with you and will see your name, surname and date of birth:\n%s\n\nTo disable the link, send /share off",
+	"share.off":           "The link is disabled. Those who added you can no longer check compatibility with you",
+	"share.own_link":      "This is your own link, send it to your partner",
+	"share.invalid_link":  "The link is not valid. Ask your partner for a new one from /share",
+
+	"subscribe.choose_time":     "Choose the time to send the daily forecast at:",
+	"subscribe.current":         "You receive the forecast %s.\n",
+	"subscribe.choose_timezone": "Choose your timezone. If it is not listed, send /subscribe %s <timezone>, e.g. Europe/London",
+	"subscribe.wrong_time":      "Could not understand the time or the timezone. Example: /subscribe 09:30 Europe/London",
+	"subscribe.done":            "You are subscribed to the forecast %s. Each forecast costs %s of your quota. Unsubscribe: /unsubscribe",
+	"subscribe.title":           "daily at %02d:%02d (%s)",
+	"subscribe.none":            "You have no forecast subscription. Subscribe: /subscribe",
+	"subscribe.off":             "The forecast subscription is turned off",
+	"subscribe.forecast":        "Forecast for %s\n\n%s",
+	"subscribe.no_quota":        "Today's forecast is not sent: your quota is over. Top it up in /payment or unsubscribe with /unsubscribe",
+
+	"timezone.Europe/Kaliningrad": "Kaliningrad",
+	"timezone.Europe/Moscow":      "Moscow",
+	"timezone.Europe/Samara":      "Samara",
+	"timezone.Asia/Yekaterinburg": "Yekaterinburg",
+	"timezone.Asia/Omsk":          "Omsk",
+	"timezone.Asia/Novosibirsk":   "Novosibirsk",
+	"timezone.Asia/Krasnoyarsk":   "Krasnoyarsk",
+	"timezone.Asia/Irkutsk":       "Irkutsk",
+	"timezone.Asia/Yakutsk":       "Yakutsk",
+	"timezone.Asia/Vladivostok":   "Vladivostok",
+	"timezone.Asia/Magadan":       "Magadan",
+	"timezone.Asia/Kamchatka":     "Kamchatka",
+	"timezone.Europe/Minsk":       "Minsk",
+	"timezone.Asia/Almaty":        "Almaty",
+	"timezone.Europe/London":      "London",
+	"timezone.Europe/Berlin":      "Berlin",
+	"timezone.America/New_York":   "New York",
+	"timezone.UTC":                "UTC",
+
+	"language.choose":  "Choose the language:",
+	"language.changed": "The language is changed to English",
+
+	"prompt.answer_language": "The answer must be in English.",
+	"prompt.base":            "You are a numerologist. All the numerology numbers are already calculated and given together with the user's profile: don't recalculate them and don't name other values of the numbers, use only the given ones. If the profile has the birth time (BirthTime, local) and the birthplace (BirthPlace with the coordinates and the timezone), you may rely on them too. Try to be mysterious but sound as truthful as possible. ",
+
+	"prompt.task.general":       "Give the user a prediction: explain what their life path number and destiny number mean and tell about their strengths and weaknesses.",
+	"prompt.task.daily":         "Give a short prediction for today by the personal day number: what to expect, what to pay attention to and what to avoid.",
+	"prompt.task.personal_year": "Tell which themes and tasks the personal year and the current personal month bring to the user and give advice for this period.",
+	"prompt.task.compatibility": "Assess the compatibility of the user and the partner by their numbers and the compatibility number: how they complement each other, where conflicts are possible and how to smooth them.",
+	"prompt.task.career":        "Tell in which professions and fields the user will do best, which work style suits them and what holds their career back. Take the workplace and the place of study into account if they are given.",
+	"prompt.task.name":          "Analyze the user's name: what the destiny number, the soul urge number and the personality number say about them and how the name affects the character and the fate.",
+	"prompt.task.house":         "Tell which energy the house or apartment number carries and how well it suits the user, give advice on how to harmonize the home.",
+	"prompt.task.lucky_dates":   "Tell why the given lucky dates of the coming month are favorable for the user and which matters each of them suits best.",
+
+	"prompt.daily":         "Today: %s\nPersonal day number: %d\n",
+	"prompt.personal_year": "Current year: %d\nPersonal year number: %d\nPersonal month number: %d\n",
+	"prompt.partner":       "Partner: %s %s\nPartner's birth date: %s\n",
+	"prompt.partner_chart": "Partner's numbers",
+	"prompt.compatibility": "Compatibility number: %d\n",
+	"prompt.house":         "House or apartment: %s\nIts number: %d\n",
+	"prompt.lucky_dates":   "Lucky dates of the coming 30 days: %s\n",
+	"prompt.no_dates":      "none",
+
+	"chart.title":       "Calculated numbers (don't recalculate them)",
+	"chart.life_path":   "Life path number",
+	"chart.expression":  "Destiny (expression) number",
+	"chart.soul_urge":   "Soul urge number",
+	"chart.personality": "Personality number",
+	"chart.birthday":    "Birthday number",
+	"chart.maturity":    "Maturity number",
+
+	"admin.help":            "Admin commands:\n/admin_user <id|@username> — profile and quota\n/admin_grant <id|@username> <n> — add predictions\n/admin_ban <id|@username> [off] — block or unblock\n/admin_stats — statistics\n/admin_broadcast [md] <text> — message all the users, md turns on Markdown. The command may be a reply to a text or a photo. The last lines «Title | https://link» become buttons\n/admin_broadcasts — latest broadcasts\n/admin_audit — latest admin commands\n/admin_dead_letters — undelivered messages",
+	"admin.no_dead_letters": "There are no undelivered messages",
+	"admin.user_not_found":  "User %s not found",
+	"admin.user":            "User %d\nPredictions available: %d\nPredictions made: %d",
+	"admin.wrong_number":    "Wrong number: %s",
+	"admin.granted":         "Added %d predictions to user %d, %d available now",
+	"admin.granted_user":    "You have been granted predictions: %d",
+	"admin.ban_admin":       "An admin can't be banned",
+	"admin.banned":          "User %d is banned",
+	"admin.unbanned":        "User %d is unbanned",
+	"admin.stats":           "Users: %d\nFilled profiles: %d\nSubscribed to forecasts: %d\nBanned: %d\nPredictions made: %d",
+
+	"broadcast.status.draft":     "Broadcast %s of %s waits for the confirmation",
+	"broadcast.status.running":   "Broadcast %s of %s is running",
+	"broadcast.status.finished":  "Broadcast %s of %s is finished",
+	"broadcast.status.cancelled": "Broadcast %s of %s is cancelled",
+	"broadcast.stats":            "Sent: %d\nBlocked the bot: %d\nFailed: %d\nSkipped: %d",
+	"broadcast.duration":         "Duration: %s",
+	"broadcast.preview_failed":   "Couldn't send the preview: %s",
+	"broadcast.confirm":          "The preview of the broadcast is above. Send it to all the users?",
+	"broadcast.start":            "Send to all",
+	"broadcast.cancel":           "Cancel",
+	"broadcast.stop":             "Stop",
+	"broadcast.empty":            "There were no broadcasts yet",
+	"broadcast.not_found":        "Broadcast not found",
+	"broadcast.started":          "Broadcast %s has started, I'll send the report when it's finished. Progress: /admin_broadcasts",
+	"broadcast.stopping":         "Broadcast %s is stopping",
+}
@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	Russian = "ru"
+	English = "en"
+	// Default is used for unknown languages and for the messages missing in a catalog.
+	Default = Russian
+)
+
+// Language is a language the bot speaks, Title is shown in its own language.
+type Language struct {
+	Code  string
+	Title string
+}
+
+var Languages = []Language{
+	{Russian, "Русский"},
+	{English, "English"},
+}
+
+// catalogs maps the language code to the messages keyed by ID. Plural messages have
+// a key per form: "<id>#one", "<id>#few", "<id>#many" and "<id>#other".
+var catalogs = map[string]map[string]string{
+	Russian: russian,
+	English: english,
+}
+
+// Detect chooses the language for the Telegram language code of the user:
+// the language with a catalog for the code or Default.
+func Detect(languageCode string) string {
+	code, _, _ := strings.Cut(strings.ToLower(languageCode), "-")
+	return Supported(code)
+}
+
+// Supported returns lang when there is a catalog for it and Default otherwise.
+func Supported(lang string) string {
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return Default
+}
+
+func lookup(lang, id string) (string, bool) {
+	if msg, ok := catalogs[Supported(lang)][id]; ok {
+		return msg, true
+	}
+	msg, ok := catalogs[Default][id]
+	return msg, ok
+}
+
+// T returns the message in the language formatted with args. The id itself is returned for unknown messages.
+func T(lang, id string, args ...any) string {
+	msg, ok := lookup(lang, id)
+	if !ok {
+		return id
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Plural returns the form of the message matching n, formatted with n.
+func Plural(lang, id string, n int64) string {
+	lang = Supported(lang)
+	msg, ok := lookup(lang, id+"#"+pluralForm(lang, n))
+	if !ok {
+		msg, ok = lookup(lang, id+"#other")
+	}
+	if !ok {
+		return id
+	}
+	return fmt.Sprintf(msg, n)
+}
+
+// Error is T for the messages sent with SendError.
+func Error(lang, id string) error {
+	return errors.New(T(lang, id))
+}
+
+func pluralForm(lang string, n int64) string {
+	if n < 0 {
+		n = -n
+	}
+	switch lang {
+	case Russian:
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+// IDs of the error messages sent with SendError.
+const (
+	ErrUnknownCommand  = "error.unknown_command"
+	ErrGotSomeProblems = "error.problems"
+	ErrFillRequired    = "error.fill_required"
+	ErrContentFiltered = "error.content_filtered"
+	ErrContextTooLong  = "error.context_too_long"
+)
@@ -0,0 +1,228 @@
+package i18n
+
+var russian = map[string]string{
+	"intro":    "Я помогу вам понять свою суть с помощью цифр и не только:\n1. /profile - заполните свой профиль \n2. /predictions - узнайте возможные варианты предсказаний\n3. /help - узнайте больше возможностей бота\nДля достижения более точного прогноза рекомендуется заполнить все поля",
+	"help":     "Доступные команды:\n/profile - ваш профиль для презсказаний\n/predictions - узнать предсказание от бота нумеролога. Ответьте на сообщение с предсказанием, чтобы задать вопрос\n/history - история ваших предсказаний\n/subscribe - ежедневный прогноз на день в выбранное время\n/unsubscribe - отключить ежедневный прогноз\n/share - ссылка, по которой партнёр сможет рассчитать совместимость с вами\n/payment - просмотр квоты по запросам и ее пополнение\n/reset - очистка профиля. Квота сохранится\n/language - язык бота\n/feedback - оставить фидбек\n/intro - начальное сообщение бота\n/stop - отмена ввода в режиме изменения профиля\n/help - мануал по доступным командам",
+	"feedback": "Вы можете оставить отзыв о боте по ссылке ниже:\nhttps://docs.google.com/forms/d/1Txnv0dsKpI5Lcf0bI2AH3Mw1Ly0RP5okOEA-OlYHw6U/edit",
+
+	ErrUnknownCommand:  "Неизвестная комманда",
+	ErrGotSomeProblems: "Произошли проблемы при работе, пожалуйста попробуйте позже",
+	ErrFillRequired:    "Пожалуйста заполните в профиле все обязательные поля",
+	ErrContentFiltered: "Модель отказалась отвечать на этот запрос, попробуйте сформулировать его иначе",
+	ErrContextTooLong:  "Разговор стал слишком длинным для модели, закажите новый прогноз",
+
+	"predictions#one":  "%d предсказание",
+	"predictions#few":  "%d предсказания",
+	"predictions#many": "%d предсказаний",
+
+	"profile.title":            "*Ваш профиль*:\n",
+	"profile.required":         "_(обязательно)_",
+	"profile.name":             "Имя",
+	"profile.surname":          "Фамилия",
+	"profile.birthdate":        "Дата рождения",
+	"profile.birthtime":        "Время рождения",
+	"profile.birthplace":       "Место рождения",
+	"profile.workplace":        "Место работы",
+	"profile.studyplace":       "Место учёбы",
+	"profile.hobby":            "Хобби",
+	"profile.bio":              "Биография",
+	"profile.edit_name":        "Изменить имя",
+	"profile.edit_surname":     "Изменить фамилию",
+	"profile.edit_birthdate":   "Изменить дату рождения",
+	"profile.edit_birthtime":   "Изменить время рождения",
+	"profile.edit_birthplace":  "Изменить место рождения",
+	"profile.edit_workplace":   "Изменить место работы",
+	"profile.edit_studyplace":  "Изменить место учёбы",
+	"profile.edit_hobby":       "Изменить хобби",
+	"profile.edit_bio":         "Изменить биографию",
+	"profile.enter_name":       "Введите ваше имя:",
+	"profile.enter_surname":    "Введите вашу фамилию:",
+	"profile.enter_birthdate":  "Введите вашу дату рождения, например 05.03.1990 или 5 марта 1990, или выберите её в календаре:",
+	"profile.enter_birthtime":  "Введите время рождения по местному времени, например 09:30:",
+	"profile.enter_birthplace": "Введите город рождения, например Москва или Брест, Беларусь:",
+	"profile.enter_bio":        "Введите вашу биографию:",
+	"profile.enter_workplace":  "Введите ваше место работы:",
+	"profile.enter_studyplace": "Введите ваше место учёбы:",
+	"profile.enter_hobby":      "Опишите ваше хобби:",
+	"profile.updated":          "Ваш профиль обновлен",
+	"profile.reset":            "Данные о вашем профиле очищены",
+	"profile.use_buttons":      "Чтобы отредактировать поле, используйте кнопки в меню профиля",
+	"profile.wrong_date":       "Не удалось разобрать дату, напишите её как 05.03.1990 или 5 марта 1990 либо выберите в календаре",
+	"profile.ambiguous_date":   "Непонятно, %s или %s? Напишите месяц словом, например 5 марта 1990, или выберите дату в календаре",
+	"profile.wrong_time":       "Не удалось разобрать время, напишите его как 09:30",
+	"profile.unknown_place":    "Не знаю такого города. Напишите ближайший крупный город или место и его часовой пояс через запятую, например Урюпинск, UTC+3 или Урюпинск, Europe/Moscow",
+	"profile.ambiguous_place":  "Есть несколько городов с таким названием, добавьте страну через запятую:\n%s",
+	"calendar.open":            "📅 Выбрать в календаре",
+	"calendar.months":          "Янв Фев Мар Апр Май Июн Июл Авг Сен Окт Ноя Дек",
+	"calendar.weekdays":        "Пн Вт Ср Чт Пт Сб Вс",
+	"calendar.expired":         "Дата сейчас не запрашивается",
+
+	"validation.empty":   "Значение не может быть пустым",
+	"validation.min":     "Слишком короткое значение, минимальная длина: %d",
+	"validation.max":     "Слишком длинное значение, максимальная длина: %d",
+	"validation.script":  "Используйте буквы только одного алфавита, пробел и дефис",
+	"validation.text":    "Текст содержит недопустимые символы",
+	"validation.future":  "Дата не может быть в будущем",
+	"validation.min_age": "Минимальный возраст: %d",
+	"validation.max_age": "Максимальный возраст: %d",
+
+	"edit.stop_hint":   "Напишите /stop для отмены ввода",
+	"edit.waiting":     "Ожидаю ввода...",
+	"edit.not_editing": "Вы не находитесь в режиме изменения профиля",
+	"edit.cancelled":   "Ввод отменен:\n",
+	"dialog.cancelled": "Ввод отменен",
+
+	"wizard.start":       "Давайте заполним ваш профиль. Сначала обязательные поля, остальные можно пропустить",
+	"wizard.skip_hint":   "Чтобы пропустить, напишите /skip",
+	"wizard.cannot_skip": "Этот шаг нельзя пропустить",
+	"wizard.done":        "Профиль заполнен! Теперь вы можете получить предсказание в /predictions\n\n",
+	"wizard.cancelled":   "Вы можете заполнить профиль позже в /profile",
+
+	"payment.status":              "Количество оставшийся предсказаний: %d\nКоличество сделанных предсказаний: %d\nВы можете купить дополнительные предсказания за Telegram Stars по кнопкам ниже",
+	"payment.package":             "%s — %d ⭐",
+	"payment.history_button":      "История покупок",
+	"payment.package_unavailable": "Пакет больше недоступен",
+	"payment.invoice_description": "Пополнение квоты на %s",
+	"payment.no_payments":         "У вас пока нет покупок",
+	"payment.history":             "История покупок:\n",
+	"payment.history_row":         "%s: +%s за %d %s\n",
+	"payment.pre_checkout_failed": "Этот пакет больше недоступен, откройте /payment и выберите пакет заново",
+	"payment.thanks":              "Спасибо за покупку! Квота увеличена на %s",
+	"payment.pending":             "Ваш платёж сохранён, предсказания будут добавлены к квоте в течение нескольких минут",
+	"payment.not_recorded":        "Не удалось сохранить ваш платёж из-за технической ошибки. Пожалуйста, отправьте этот код в поддержку через /feedback, предсказания будут начислены вручную: %s",
+
+	"history.empty":     "У вас пока нет сохранённых предсказаний. Получите первое командой /predictions",
+	"history.page":      "История предсказаний (страница %d из %d):\n\n",
+	"history.show":      "Показать %d",
+	"history.delete":    "Удалить %d",
+	"history.back":      "◀️ Назад",
+	"history.forward":   "Вперёд ▶️",
+	"history.not_found": "Предсказание не найдено",
+	"history.deleted":   "Предсказание удалено",
+
+	"predictions.menu":         "Выберите предсказание. В скобках указано, сколько предсказаний из квоты оно стоит:",
+	"predictions.unavailable":  "Такого предсказания больше нет",
+	"predictions.wrong_input":  "Не удалось распознать ответ, попробуйте ещё раз",
+	"predictions.missing_data": "Для этого предсказания в профиле не хватает данных, проверьте их в /profile",
+	"predictions.no_quota":     "Недостаточно квоты: это предсказание стоит %s. Пополните ее в разделе /payment",
+	"predictions.waiting":      "Ожидаю нумерологический прогноз...",
+
+	"prediction.general":       "Общий прогноз",
+	"prediction.daily":         "Прогноз на сегодня",
+	"prediction.personal_year": "Личный год и месяц",
+	"prediction.compatibility": "Совместимость",
+	"prediction.career":        "Карьера и призвание",
+	"prediction.name":          "Анализ имени",
+	"prediction.house":         "Номер дома или квартиры",
+	"prediction.house.input":   "Введите номер дома или квартиры, например 12Б:",
+	"prediction.lucky_dates":   "Удачные даты",
+
+	"conversation.expired":   "Разговор об этом предсказании завершён. Получите новое предсказание в /predictions и ответьте на него, чтобы задать вопрос",
+	"conversation.text_only": "Задайте вопрос текстом в ответ на сообщение с предсказанием",
+	"conversation.no_quota":  "Недостаточно квоты для вопроса. Пополните ее в разделе /payment",
+	"conversation.waiting":   "Обдумываю ваш вопрос...",
+
+	"partners.menu":       "Выберите партнёра для расчёта совместимости или добавьте нового.\nПартнёр, который пользуется ботом, может отправить вам свою ссылку из /share",
+	"partners.add":        "Добавить партнёра",
+	"partners.not_found":  "Партнёр не найден",
+	"partners.not_shared": "Партнёр закрыл доступ к своему профилю. Попросите у него новую ссылку из /share или удалите его из партнёров",
+	"partners.deleted":    "Партнёр удалён",
+	"partners.enter_name": "Введите имя и фамилию партнёра:",
+	"partners.enter_date": "Введите дату рождения партнёра, например 05.03.1990 или 5 марта 1990, или выберите её в календаре:",
+	"partners.saved":      "Партнёр сохранён, он будет доступен в меню совместимости",
+	"partners.added":      "%s добавлен(а) в ваши партнёры",
+	"share.link":          "Отправьте эту ссылку партнёру. Открыв её, он сможет рассчитать совместимость с вами, ему будут видны ваши имя, фамилия и дата рождения:\n%s\n\nЧтобы отключить ссылку, напишите /share off",
+	"share.off":           "Ссылка отключена. Те, кто добавил вас, больше не смогут рассчитать совместимость с вами",
+	"share.own_link":      "Это ваша собственная ссылка, отправьте её партнёру",
+	"share.invalid_link":  "Ссылка недействительна. Попросите партнёра прислать новую из /share",
+
+	"subscribe.choose_time":     "Выберите время, в которое присылать прогноз на день:",
+	"subscribe.current":         "Сейчас вы получаете прогноз %s.\n",
+	"subscribe.choose_timezone": "Выберите часовой пояс. Если вашего нет в списке, напишите /subscribe %s <часовой пояс>, например Europe/Moscow",
+	"subscribe.wrong_time":      "Не удалось распознать время или часовой пояс. Пример: /subscribe 09:30 Europe/Moscow",
+	"subscribe.done":            "Вы подписаны на прогноз на день %s. Каждый прогноз стоит %s из квоты. Отписаться: /unsubscribe",
+	"subscribe.title":           "ежедневно в %02d:%02d (%s)",
+	"subscribe.none":            "У вас нет подписки на прогноз. Подписаться: /subscribe",
+	"subscribe.off":             "Подписка на прогноз отключена",
+	"subscribe.forecast":        "Прогноз на %s\n\n%s",
+	"subscribe.no_quota":        "Прогноз на сегодня не отправлен: закончилась квота. Пополните ее в разделе /payment или отключите подписку командой /unsubscribe",
+
+	"timezone.Europe/Kaliningrad": "Калининград",
+	"timezone.Europe/Moscow":      "Москва",
+	"timezone.Europe/Samara":      "Самара",
+	"timezone.Asia/Yekaterinburg": "Екатеринбург",
+	"timezone.Asia/Omsk":          "Омск",
+	"timezone.Asia/Novosibirsk":   "Новосибирск",
+	"timezone.Asia/Krasnoyarsk":   "Красноярск",
+	"timezone.Asia/Irkutsk":       "Иркутск",
+	"timezone.Asia/Yakutsk":       "Якутск",
+	"timezone.Asia/Vladivostok":   "Владивосток",
+	"timezone.Asia/Magadan":       "Магадан",
+	"timezone.Asia/Kamchatka":     "Камчатка",
+	"timezone.Europe/Minsk":       "Минск",
+	"timezone.Asia/Almaty":        "Алматы",
+	"timezone.Europe/London":      "Лондон",
+	"timezone.Europe/Berlin":      "Берлин",
+	"timezone.America/New_York":   "Нью-Йорк",
+	"timezone.UTC":                "UTC",
+
+	"language.choose":  "Выберите язык:",
+	"language.changed": "Язык изменён на русский",
+
+	"prompt.answer_language": "Ответ должен быть на русском языке.",
+	"prompt.base":            "Ты нумеролог. Все нумерологические числа уже рассчитаны и переданы вместе с профилем пользователя: ничего не пересчитывай и не называй других значений чисел, используй только переданные. Если в профиле указаны время (BirthTime, местное) и место рождения (BirthPlace с координатами и часовым поясом), можешь опираться и на них. Старайся быть загадочным но в то же время звучать максимально правдиво. ",
+
+	"prompt.task.general":       "Дай пользователю прогноз: объясни, что означает его число жизненного пути и число судьбы, а также расскажи про его сильные и слабые стороны.",
+	"prompt.task.daily":         "Дай короткий прогноз на сегодняшний день по числу личного дня: чего ожидать, на что обратить внимание и чего избегать.",
+	"prompt.task.personal_year": "Расскажи, какие темы и задачи несёт пользователю его личный год и текущий личный месяц, дай советы на этот период.",
+	"prompt.task.compatibility": "Оцени совместимость пользователя с партнёром по их числам и числу совместимости: в чём они дополняют друг друга, где возможны конфликты и как их сгладить.",
+	"prompt.task.career":        "Расскажи, в каких профессиях и сферах пользователь раскроется лучше всего, какой стиль работы ему подходит и что мешает его карьере. Учитывай место работы и учёбы, если они указаны.",
+	"prompt.task.name":          "Проанализируй имя пользователя: что говорят о нём число судьбы, число души и число личности, как имя влияет на характер и судьбу.",
+	"prompt.task.house":         "Расскажи, какую энергию несёт номер дома или квартиры и насколько он подходит пользователю, дай советы, как гармонизировать жильё.",
+	"prompt.task.lucky_dates":   "Расскажи, чем благоприятны для пользователя переданные удачные даты ближайшего месяца и для каких дел каждая из них подходит лучше всего.",
+
+	"prompt.daily":         "Сегодня: %s\nЧисло личного дня: %d\n",
+	"prompt.personal_year": "Текущий год: %d\nЧисло личного года: %d\nЧисло личного месяца: %d\n",
+	"prompt.partner":       "Партнёр: %s %s\nДата рождения партнёра: %s\n",
+	"prompt.partner_chart": "Числа партнёра",
+	"prompt.compatibility": "Число совместимости: %d\n",
+	"prompt.house":         "Номер: %s\nЧисло номера: %d\n",
+	"prompt.lucky_dates":   "Удачные даты ближайших 30 дней: %s\n",
+	"prompt.no_dates":      "нет",
+
+	"chart.title":       "Рассчитанные числа (не пересчитывай их)",
+	"chart.life_path":   "Число жизненного пути",
+	"chart.expression":  "Число судьбы (выражения)",
+	"chart.soul_urge":   "Число души",
+	"chart.personality": "Число личности",
+	"chart.birthday":    "Число дня рождения",
+	"chart.maturity":    "Число зрелости",
+
+	"admin.help":            "Команды администратора:\n/admin_user <id|@username> — профиль и квота\n/admin_grant <id|@username> <n> — добавить прогнозы\n/admin_ban <id|@username> [off] — заблокировать или разблокировать\n/admin_stats — статистика\n/admin_broadcast [md] <текст> — рассылка всем пользователям, md включает Markdown. Можно ответить этой командой на текст или фото. Строки в конце вида «Название | https://ссылка» становятся кнопками\n/admin_broadcasts — последние рассылки\n/admin_audit — последние команды администраторов\n/admin_dead_letters — недоставленные сообщения",
+	"admin.no_dead_letters": "Недоставленных сообщений нет",
+	"admin.user_not_found":  "Пользователь %s не найден",
+	"admin.user":            "Пользователь %d\nДоступно прогнозов: %d\nСделано прогнозов: %d",
+	"admin.wrong_number":    "Неправильное число: %s",
+	"admin.granted":         "Добавлено %d прогнозов пользователю %d, теперь доступно %d",
+	"admin.granted_user":    "Вам начислено прогнозов: %d",
+	"admin.ban_admin":       "Нельзя заблокировать администратора",
+	"admin.banned":          "Пользователь %d заблокирован",
+	"admin.unbanned":        "Пользователь %d разблокирован",
+	"admin.stats":           "Пользователей: %d\nЗаполнили профиль: %d\nПодписаны на прогнозы: %d\nЗаблокировано: %d\nСделано прогнозов: %d",
+
+	"broadcast.status.draft":     "Рассылка %s от %s ждёт подтверждения",
+	"broadcast.status.running":   "Рассылка %s от %s идёт",
+	"broadcast.status.finished":  "Рассылка %s от %s закончена",
+	"broadcast.status.cancelled": "Рассылка %s от %s отменена",
+	"broadcast.stats":            "Отправлено: %d\nЗаблокировали бота: %d\nОшибок: %d\nПропущено: %d",
+	"broadcast.duration":         "Длительность: %s",
+	"broadcast.preview_failed":   "Не удалось отправить предпросмотр: %s",
+	"broadcast.confirm":          "Выше предпросмотр рассылки. Отправить её всем пользователям?",
+	"broadcast.start":            "Отправить всем",
+	"broadcast.cancel":           "Отменить",
+	"broadcast.stop":             "Остановить",
+	"broadcast.empty":            "Рассылок ещё не было",
+	"broadcast.not_found":        "Рассылка не найдена",
+	"broadcast.started":          "Рассылка %s началась, пришлю отчёт, когда она закончится. Ход рассылки: /admin_broadcasts",
+	"broadcast.stopping":         "Рассылка %s останавливается",
+}
@@ -0,0 +1,340 @@
+// Package markdown converts the Markdown written by the model to the HTML understood by Telegram
+// and splits long texts into messages.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	headingRe = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	bulletRe  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	ruleRe    = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	quoteRe   = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	linkRe    = regexp.MustCompile(`^\[([^\]\n]+)\]\((https?://[^)\s]+)\)`)
+)
+
+// ToHTML renders text as Telegram HTML. Headings become bold lines, list items get bullets,
+// emphasis, code, strikethrough, links and quotes are converted to tags.
+// Unbalanced markers are kept as they are, so the result is always valid HTML.
+func ToHTML(text string) string {
+	var out []string
+	var quote []string
+	flushQuote := func() {
+		if len(quote) > 0 {
+			out = append(out, "<blockquote>"+strings.Join(quote, "\n")+"</blockquote>")
+			quote = nil
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if lang, ok := fence(line); ok {
+			flushQuote()
+			var code []string
+			for i++; i < len(lines); i++ {
+				if _, ok := fence(lines[i]); ok {
+					break
+				}
+				code = append(code, html.EscapeString(lines[i]))
+			}
+			open := "<pre>"
+			if lang != "" {
+				open = `<pre><code class="language-` + html.EscapeString(lang) + `">`
+			}
+			block := open + strings.Join(code, "\n")
+			if lang != "" {
+				block += "</code>"
+			}
+			out = append(out, block+"</pre>")
+			continue
+		}
+		if m := quoteRe.FindStringSubmatch(line); m != nil {
+			quote = append(quote, inline(m[1]))
+			continue
+		}
+		flushQuote()
+		switch {
+		case ruleRe.MatchString(line):
+			out = append(out, "———")
+		case headingRe.MatchString(line):
+			out = append(out, "<b>"+inline(headingRe.FindStringSubmatch(line)[1])+"</b>")
+		case bulletRe.MatchString(line):
+			m := bulletRe.FindStringSubmatch(line)
+			out = append(out, m[1]+"• "+inline(m[2]))
+		default:
+			out = append(out, inline(line))
+		}
+	}
+	flushQuote()
+	return strings.Join(out, "\n")
+}
+
+// fence reports whether the line opens or closes a code block and returns its language.
+func fence(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "```") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(trimmed, "```")), true
+}
+
+// pairs are the emphasis markers in the order they are tried.
+var pairs = []struct{ marker, tag string }{
+	{"**", "b"},
+	{"__", "b"},
+	{"~~", "s"},
+	{"*", "i"},
+	{"_", "i"},
+}
+
+// inline renders the emphasis, code and links of a line.
+func inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		if rest[0] == '\\' && len(rest) > 1 && isPunct(rest[1]) {
+			b.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+			continue
+		}
+		if rest[0] == '`' {
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				b.WriteString("<code>" + html.EscapeString(rest[1:1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+		}
+		if m := linkRe.FindStringSubmatch(rest); m != nil {
+			b.WriteString(`<a href="` + html.EscapeString(m[2]) + `">` + inline(m[1]) + "</a>")
+			i += len(m[0])
+			continue
+		}
+		if n, rendered := emphasis(s, i); n > 0 {
+			b.WriteString(rendered)
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(rest)
+		b.WriteString(html.EscapeString(string(r)))
+		i += size
+	}
+	return b.String()
+}
+
+// emphasis renders the emphasis starting at s[i] and returns the number of the bytes consumed, 0 when there is none.
+func emphasis(s string, i int) (int, string) {
+	for _, p := range pairs {
+		if !strings.HasPrefix(s[i:], p.marker) {
+			continue
+		}
+		start := i + len(p.marker)
+		if start >= len(s) || s[start] == ' ' || (p.marker[0] == '_' && wordBefore(s, i)) {
+			// An opening marker is followed by text, snake_case words are not italic.
+			return 0, ""
+		}
+		end := closing(s, start, p.marker)
+		if end < 0 {
+			return 0, ""
+		}
+		return end + len(p.marker) - i, "<" + p.tag + ">" + inline(s[start:end]) + "</" + p.tag + ">"
+	}
+	return 0, ""
+}
+
+// closing finds the marker closing the emphasis opened before s[start], -1 when it is unbalanced.
+func closing(s string, start int, marker string) int {
+	for j := start + 1; j <= len(s)-len(marker); j++ {
+		switch {
+		case s[j] == '`':
+			// Markers inside code do not close the emphasis.
+			if end := strings.IndexByte(s[j+1:], '`'); end >= 0 {
+				j += end + 1
+			}
+			continue
+		case len(marker) == 1 && strings.HasPrefix(s[j:], marker+marker):
+			// A double marker inside a single one, e.g. *a **b** c*.
+			j++
+			continue
+		case !strings.HasPrefix(s[j:], marker) || s[j-1] == ' ':
+			continue
+		case marker[0] == '_' && wordAfter(s, j+len(marker)):
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+func wordBefore(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return i > 0 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func wordAfter(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return i < len(s) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("\\`*_{}[]()#+-.!|~<>=", c) >= 0
+}
+
+// Length is the length of the text as counted by Telegram, in UTF-16 code units.
+func Length(text string) int {
+	n := 0
+	for _, r := range text {
+		n += max(utf16.RuneLen(r), 1)
+	}
+	return n
+}
+
+// Split cuts the Markdown text into parts whose HTML fits into limit. The text is cut between paragraphs,
+// a paragraph too long is cut between lines and a line between words. A code block cut in two is
+// closed in the first part and opened again in the next one.
+func Split(text string, limit int) []string {
+	s := splitter{limit: limit}
+	for _, block := range paragraphs(text) {
+		if !s.add(block, "\n\n") {
+			s.addLines(block)
+		}
+	}
+	s.flush()
+	return s.parts
+}
+
+type splitter struct {
+	limit   int
+	parts   []string
+	current string
+	// opening is the fence of the code block being cut, every next part starts with it.
+	opening string
+}
+
+func (s *splitter) fits(part string) bool {
+	return Length(ToHTML(part)) <= s.limit
+}
+
+func (s *splitter) flush() {
+	if strings.TrimSpace(s.current) != "" {
+		s.parts = append(s.parts, s.current)
+	}
+	s.current = ""
+}
+
+// add appends the block to the current part or starts the next part with it.
+// Returns false when the block does not fit into a part alone.
+func (s *splitter) add(block, sep string) bool {
+	if s.current != "" {
+		if s.fits(s.current + sep + block) {
+			s.current += sep + block
+			return true
+		}
+		s.flush()
+	}
+	start := block
+	if s.opening != "" {
+		start = s.opening + "\n" + block
+	}
+	if !s.fits(start) {
+		return false
+	}
+	s.current = start
+	return true
+}
+
+// addLines adds the paragraph which does not fit into a part line by line.
+func (s *splitter) addLines(block string) {
+	for _, line := range strings.Split(block, "\n") {
+		_, isFence := fence(line)
+		if isFence && s.opening != "" {
+			// The code block is closed by ToHTML when the fence does not fit.
+			if s.current != "" && s.fits(s.current+"\n"+line) {
+				s.current += "\n" + line
+			} else {
+				s.flush()
+			}
+			s.opening = ""
+			continue
+		}
+		if !s.add(line, "\n") {
+			for _, piece := range splitLine(line, s.limit-Length(ToHTML(s.opening))) {
+				if !s.add(piece, " ") {
+					// Only a piece of a single character longer than the limit gets here, it is never lost.
+					s.flush()
+					s.parts = append(s.parts, piece)
+				}
+			}
+		}
+		if isFence {
+			s.opening = line
+		}
+	}
+	s.opening = ""
+}
+
+// paragraphs cuts the text at the blank lines outside the code blocks.
+func paragraphs(text string) []string {
+	var blocks []string
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if _, ok := fence(line); ok {
+			inCode = !inCode
+		}
+		if !inCode && strings.TrimSpace(line) == "" {
+			if len(lines) > 0 {
+				blocks = append(blocks, strings.Join(lines, "\n"))
+				lines = nil
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return blocks
+}
+
+// splitLine cuts the line into pieces of at most limit characters, between words when possible.
+func splitLine(line string, limit int) []string {
+	// Escaping may make the HTML up to five times longer than the text.
+	limit = max(limit/5, 1)
+	var pieces []string
+	piece := ""
+	for _, word := range strings.Fields(line) {
+		for Length(word) > limit {
+			runes := []rune(word)
+			// limit is in UTF-16 units, a rune may take two of them.
+			n := min(limit, len(runes))
+			for n > 1 && Length(string(runes[:n])) > limit {
+				n--
+			}
+			if piece != "" {
+				pieces = append(pieces, piece)
+				piece = ""
+			}
+			pieces = append(pieces, string(runes[:n]))
+			word = string(runes[n:])
+		}
+		if piece != "" && Length(piece)+1+Length(word) > limit {
+			pieces = append(pieces, piece)
+			piece = ""
+		}
+		if piece != "" {
+			piece += " "
+		}
+		piece += word
+	}
+	if piece != "" {
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
@@ -0,0 +1,144 @@
+package markdown
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// checkHTML fails when the tags of the HTML are not balanced.
+func checkHTML(t *testing.T, h string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader("<r>" + h + "</r>"))
+	for {
+		_, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("invalid HTML %q: %v", h, err)
+		}
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"heading", "## Число судьбы 7", "<b>Число судьбы 7</b>"},
+		{"emphasis", "**bold** *italic* __bold__ _italic_ ~~strike~~", "<b>bold</b> <i>italic</i> <b>bold</b> <i>italic</i> <s>strike</s>"},
+		{"nested", "*a **b** c*", "<i>a <b>b</b> c</i>"},
+		{"unbalanced star", "unbalanced *star and **bold", "unbalanced *star and **bold"},
+		{"unbalanced underscore", "a _b and c", "a _b and c"},
+		{"snake case", "snake_case_word", "snake_case_word"},
+		{"space after marker", "2 * 3 = 6 * 1", "2 * 3 = 6 * 1"},
+		{"escaped", `\*not\* <b> & co`, "*not* &lt;b&gt; &amp; co"},
+		{"code", "`a*b<c` and *i*", "<code>a*b&lt;c</code> and <i>i</i>"},
+		{"marker in code", "**bold `code**` end**", "<b>bold <code>code**</code> end</b>"},
+		{"link", "[site](https://x.com/?a=1&b=2)", `<a href="https://x.com/?a=1&amp;b=2">site</a>`},
+		{"not a link", "[site](javascript:alert)", "[site](javascript:alert)"},
+		{"list", "- one\n* two\n  + three", "• one\n• two\n  • three"},
+		{"rule", "---", "———"},
+		{"quote", "> a *b*\n> c\nd", "<blockquote>a <i>b</i>\nc</blockquote>\nd"},
+		{"code block", "```go\nif a < b {}\n```", `<pre><code class="language-go">if a &lt; b {}</code></pre>`},
+		{"unclosed code block", "```\n*x*", "<pre>*x*</pre>"},
+		{"emoji", "😀 *😀*", "😀 <i>😀</i>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToHTML(tt.in)
+			if got != tt.want {
+				t.Errorf("ToHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			checkHTML(t, got)
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"абв", 3},
+		{"😀", 2},
+		{"a😀b", 4},
+	}
+	for _, tt := range tests {
+		if got := Length(tt.in); got != tt.want {
+			t.Errorf("Length(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	paragraph := "Это **абзац** с <знаками> & " + strings.Repeat("слово ", 60)
+	tests := []struct {
+		name  string
+		in    string
+		limit int
+		parts int
+	}{
+		{"empty", " \n\n ", 100, 0},
+		{"short", "short *text*", 4096, 1},
+		{"paragraphs", strings.Repeat(paragraph+"\n\n", 30), 4096, 3},
+		{"code block", "```go\n" + strings.Repeat("x := a < b\n", 1000) + "```", 4096, 4},
+		{"long word", strings.Repeat("длинноеслово", 1000), 4096, 0},
+		{"surrogate pairs", strings.Repeat("😀", 2100), 4096, 0},
+		{"surrogate pairs tiny limit", strings.Repeat("😀", 10), 1, 0},
+		{"unbalanced markers", strings.Repeat("*a _b ", 1000), 4096, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := Split(tt.in, tt.limit)
+			if tt.parts > 0 && len(parts) != tt.parts {
+				t.Errorf("got %d parts, want %d", len(parts), tt.parts)
+			}
+			if strings.TrimSpace(tt.in) != "" && len(parts) == 0 {
+				t.Fatal("no parts")
+			}
+			var text strings.Builder
+			for i, part := range parts {
+				h := ToHTML(part)
+				checkHTML(t, h)
+				if tt.limit > 1 && Length(h) > tt.limit {
+					t.Errorf("part %d is %d long", i, Length(h))
+				}
+				text.WriteString(part + "\n")
+			}
+			if got, want := content(text.String()), content(tt.in); got != want {
+				t.Errorf("text changed: got %d bytes, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+// content is the text without the whitespace and the code fences, which Split may add or drop.
+func content(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if _, ok := fence(line); !ok {
+			b.WriteString(strings.Join(strings.Fields(line), ""))
+		}
+	}
+	return b.String()
+}
+
+func TestSplitReopensCodeBlock(t *testing.T) {
+	parts := Split("```go\n"+strings.Repeat("x := 1\n", 1000)+"```\n\nafter", 4096)
+	if len(parts) < 2 {
+		t.Fatalf("got %d parts", len(parts))
+	}
+	for i, part := range parts[:len(parts)-1] {
+		if !strings.HasPrefix(part, "```go\n") {
+			t.Errorf("part %d does not open the code block: %q", i, part[:20])
+		}
+	}
+	if !strings.HasSuffix(parts[len(parts)-1], "after") {
+		t.Errorf("the text after the code block is lost")
+	}
+}
@@ -0,0 +1,226 @@
+package numerology
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/objects"
+)
+
+// Chart contains the core numbers of a person computed with the Pythagorean system.
+// Zero means that the number could not be computed (e.g. the name has no letters).
+type Chart struct {
+	LifePath    int
+	Expression  int
+	SoulUrge    int
+	Personality int
+	Birthday    int
+	Maturity    int
+}
+
+var ErrNoBirthDate = errors.New("birth date is not set")
+
+// letterValues maps every supported letter to its Pythagorean value.
+// Latin letters follow the classic A=1..I=9 cycle, Cyrillic letters the Russian table А=1..Я=6.
+var letterValues = map[rune]int{}
+
+var vowels = map[rune]bool{}
+
+func init() {
+	// The index of range over a string is in bytes, Cyrillic letters take two of them.
+	for _, alphabet := range []string{"ABCDEFGHIJKLMNOPQRSTUVWXYZ", "АБВГДЕЁЖЗИЙКЛМНОПРСТУФХЦЧШЩЪЫЬЭЮЯ"} {
+		for i, r := range []rune(alphabet) {
+			letterValues[r] = i%9 + 1
+		}
+	}
+	for _, r := range "AEIOUАЕЁИОУЫЭЮЯ" {
+		vowels[r] = true
+	}
+}
+
+func isMaster(n int) bool {
+	return n == 11 || n == 22 || n == 33
+}
+
+func digitSum(n int) int {
+	sum := 0
+	for n > 0 {
+		sum += n % 10
+		n /= 10
+	}
+	return sum
+}
+
+// Reduce sums the digits of n until a single digit or a master number is left.
+func Reduce(n int) int {
+	for n > 9 && !isMaster(n) {
+		n = digitSum(n)
+	}
+	return n
+}
+
+// LifePath reduces day, month and year separately and then reduces their sum.
+func LifePath(birthDate time.Time) int {
+	if birthDate.IsZero() {
+		return 0
+	}
+	day := Reduce(birthDate.Day())
+	month := Reduce(int(birthDate.Month()))
+	year := Reduce(birthDate.Year())
+	return Reduce(day + month + year)
+}
+
+// Birthday is the reduced day of the month of birth.
+func Birthday(birthDate time.Time) int {
+	if birthDate.IsZero() {
+		return 0
+	}
+	return Reduce(birthDate.Day())
+}
+
+// nameNumber reduces the sum of letters of every word separately and then reduces the total.
+// The filter decides which letters take part in the calculation.
+func nameNumber(name string, filter func(r rune) bool) int {
+	total := 0
+	for _, word := range strings.Fields(name) {
+		sum := 0
+		for _, r := range strings.ToUpper(word) {
+			value, ok := letterValues[r]
+			if !ok || !filter(r) {
+				continue
+			}
+			sum += value
+		}
+		total += Reduce(sum)
+	}
+	return Reduce(total)
+}
+
+// Expression (destiny number) uses all letters of the full name.
+func Expression(name string) int {
+	return nameNumber(name, func(r rune) bool { return true })
+}
+
+// SoulUrge uses only the vowels of the full name.
+func SoulUrge(name string) int {
+	return nameNumber(name, func(r rune) bool { return vowels[r] })
+}
+
+// Personality uses only the consonants of the full name.
+// Cyrillic signs Ъ and Ь are counted as consonants as in the Russian tradition.
+func Personality(name string) int {
+	return nameNumber(name, func(r rune) bool { return !vowels[r] })
+}
+
+// Maturity is the reduced sum of the life path and expression numbers.
+func Maturity(lifePath, expression int) int {
+	if lifePath == 0 || expression == 0 {
+		return 0
+	}
+	return Reduce(lifePath + expression)
+}
+
+// Compute computes the chart for the full name and birth date. Birth date is required,
+// name based numbers are left zero when the name has no supported letters.
+func Compute(name string, birthDate time.Time) (Chart, error) {
+	if birthDate.IsZero() {
+		return Chart{}, ErrNoBirthDate
+	}
+	chart := Chart{
+		LifePath:    LifePath(birthDate),
+		Birthday:    Birthday(birthDate),
+		Expression:  Expression(name),
+		SoulUrge:    SoulUrge(name),
+		Personality: Personality(name),
+	}
+	chart.Maturity = Maturity(chart.LifePath, chart.Expression)
+	return chart, nil
+}
+
+// Calculate computes the chart for the profile.
+func Calculate(profile *objects.Profile) (Chart, error) {
+	return Compute(strings.TrimSpace(profile.Name+" "+profile.Surname), profile.BirthDate)
+}
+
+// CalculatePartner computes the chart for the saved partner.
+func CalculatePartner(partner objects.Partner) (Chart, error) {
+	return Compute(strings.TrimSpace(partner.Name+" "+partner.Surname), partner.BirthDate)
+}
+
+// Compatibility is the reduced sum of the life path numbers of two people.
+func Compatibility(a, b Chart) int {
+	return Reduce(a.LifePath + b.LifePath)
+}
+
+// AIMessage formats the chart for the prompt, so the model only interprets the numbers.
+func (c Chart) AIMessage(lang string) string {
+	return c.Format(lang, i18n.T(lang, "chart.title"))
+}
+
+// Format lists the non zero numbers of the chart under the title in the language.
+func (c Chart) Format(lang, title string) string {
+	res := title + ":\n"
+	formatRow := func(id string, value int) {
+		if value == 0 {
+			return
+		}
+		res += fmt.Sprintf("%s: %d\n", i18n.T(lang, id), value)
+	}
+	formatRow("chart.life_path", c.LifePath)
+	formatRow("chart.expression", c.Expression)
+	formatRow("chart.soul_urge", c.SoulUrge)
+	formatRow("chart.personality", c.Personality)
+	formatRow("chart.birthday", c.Birthday)
+	formatRow("chart.maturity", c.Maturity)
+	return res
+}
+
+// PersonalYear combines the birthday with the calendar year of date.
+func PersonalYear(birthDate, date time.Time) int {
+	day := Reduce(birthDate.Day())
+	month := Reduce(int(birthDate.Month()))
+	year := Reduce(date.Year())
+	return Reduce(day + month + year)
+}
+
+// PersonalMonth combines the personal year with the calendar month of date.
+func PersonalMonth(birthDate, date time.Time) int {
+	return Reduce(PersonalYear(birthDate, date) + Reduce(int(date.Month())))
+}
+
+// PersonalDay combines the personal month with the day of date.
+func PersonalDay(birthDate, date time.Time) int {
+	return Reduce(PersonalMonth(birthDate, date) + Reduce(date.Day()))
+}
+
+// Address reduces the digits and letters of a house or apartment number, e.g. "12Б".
+func Address(address string) int {
+	sum := 0
+	for _, r := range strings.ToUpper(address) {
+		if r >= '0' && r <= '9' {
+			sum += int(r - '0')
+			continue
+		}
+		sum += letterValues[r]
+	}
+	return Reduce(sum)
+}
+
+// LuckyDates returns the dates within days from start whose personal day matches
+// the life path or the birthday number.
+func LuckyDates(birthDate, start time.Time, days int) []time.Time {
+	lifePath := LifePath(birthDate)
+	birthday := Birthday(birthDate)
+	var dates []time.Time
+	for i := range days {
+		date := start.AddDate(0, 0, i)
+		personalDay := PersonalDay(birthDate, date)
+		if personalDay == lifePath || personalDay == birthday {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
@@ -0,0 +1,138 @@
+package numerology
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestReduce(t *testing.T) {
+	tests := []struct {
+		n, want int
+	}{
+		{0, 0},
+		{7, 7},
+		{10, 1},
+		{11, 11},
+		{19, 1},
+		{22, 22},
+		{29, 11},
+		{33, 33},
+		{38, 11},
+		{44, 8},
+		{99, 9},
+		{1987, 7},
+	}
+	for _, tt := range tests {
+		if got := Reduce(tt.n); got != tt.want {
+			t.Errorf("Reduce(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLifePath(t *testing.T) {
+	tests := []struct {
+		birthDate          time.Time
+		lifePath, birthday int
+	}{
+		{date(1990, time.July, 15), 5, 6},
+		{date(1987, time.November, 29), 11, 11},
+		{date(2000, time.February, 2), 6, 2},
+		{time.Time{}, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := LifePath(tt.birthDate); got != tt.lifePath {
+			t.Errorf("LifePath(%s) = %d, want %d", tt.birthDate.Format(time.DateOnly), got, tt.lifePath)
+		}
+		if got := Birthday(tt.birthDate); got != tt.birthday {
+			t.Errorf("Birthday(%s) = %d, want %d", tt.birthDate.Format(time.DateOnly), got, tt.birthday)
+		}
+	}
+}
+
+func TestNameNumbers(t *testing.T) {
+	tests := []struct {
+		name                              string
+		expression, soulUrge, personality int
+	}{
+		{"John", 2, 6, 5},
+		{"Anna", 3, 2, 1},
+		{"Анна", 5, 2, 3},
+		{"анна", 5, 2, 3},
+		// Every word is reduced before the total.
+		{"John Anna", 5, 8, 6},
+		{"Anna Anna", 6, 4, 2},
+		{"Яков", 1, 4, 6},
+		{"123 !", 0, 0, 0},
+		{"", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := Expression(tt.name); got != tt.expression {
+			t.Errorf("Expression(%q) = %d, want %d", tt.name, got, tt.expression)
+		}
+		if got := SoulUrge(tt.name); got != tt.soulUrge {
+			t.Errorf("SoulUrge(%q) = %d, want %d", tt.name, got, tt.soulUrge)
+		}
+		if got := Personality(tt.name); got != tt.personality {
+			t.Errorf("Personality(%q) = %d, want %d", tt.name, got, tt.personality)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	chart, err := Compute("John", date(1990, time.July, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Chart{LifePath: 5, Expression: 2, SoulUrge: 6, Personality: 5, Birthday: 6, Maturity: 7}
+	if chart != want {
+		t.Errorf("Compute = %+v, want %+v", chart, want)
+	}
+
+	chart, err = Compute("123", date(1990, time.July, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chart.Expression != 0 || chart.Maturity != 0 {
+		t.Errorf("name without letters gave %+v", chart)
+	}
+
+	if _, err := Compute("John", time.Time{}); !errors.Is(err, ErrNoBirthDate) {
+		t.Errorf("Compute without birth date: %v", err)
+	}
+}
+
+func TestPersonalNumbers(t *testing.T) {
+	birthDate := date(1990, time.July, 15)
+	now := date(2026, time.October, 18)
+	if got := PersonalYear(birthDate, now); got != 5 {
+		t.Errorf("PersonalYear = %d, want 5", got)
+	}
+	if got := PersonalMonth(birthDate, now); got != 6 {
+		t.Errorf("PersonalMonth = %d, want 6", got)
+	}
+	if got := PersonalDay(birthDate, now); got != 6 {
+		t.Errorf("PersonalDay = %d, want 6", got)
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    int
+	}{
+		{"12Б", 5},
+		{"221b", 7},
+		{"29", 11},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := Address(tt.address); got != tt.want {
+			t.Errorf("Address(%q) = %d, want %d", tt.address, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package objects
+
+import "time"
+
+// AuditRecord is an admin command run or attempted by a user.
+type AuditRecord struct {
+	CreatedAt time.Time `json:"created_at"`
+	AdminID   int64     `json:"admin_id"`
+	Username  string    `json:"username"`
+	Command   string    `json:"command"`
+	Args      string    `json:"args,omitempty"`
+	// Allowed is false when the user is not an admin, the command was not run.
+	Allowed bool `json:"allowed"`
+}
+
+func NewAuditRecord(adminID int64, username, command, args string, allowed bool) AuditRecord {
+	return AuditRecord{
+		CreatedAt: time.Now(),
+		AdminID:   adminID,
+		Username:  username,
+		Command:   command,
+		Args:      args,
+		Allowed:   allowed,
+	}
+}
@@ -0,0 +1,75 @@
+package objects
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BroadcastDraft is shown to the admin as a preview and waits for the confirmation.
+	BroadcastDraft     = "draft"
+	BroadcastRunning   = "running"
+	BroadcastFinished  = "finished"
+	BroadcastCancelled = "cancelled"
+)
+
+// BroadcastButton is an inline button with a link under the broadcast message.
+type BroadcastButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Broadcast is a message sent to all the users. AfterID is the last user it was sent to,
+// the users are processed in the order of ids, so the broadcast resumes after a restart.
+type Broadcast struct {
+	ID          string            `json:"id"`
+	AdminID     int64             `json:"admin_id"`
+	AdminChatID int64             `json:"admin_chat_id"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Status      string            `json:"status"`
+	Text        string            `json:"text"`
+	ParseMode   string            `json:"parse_mode,omitempty"`
+	PhotoFileID string            `json:"photo_file_id,omitempty"`
+	Buttons     []BroadcastButton `json:"buttons,omitempty"`
+
+	AfterID    int64     `json:"after_id"`
+	Sent       int       `json:"sent"`
+	Failed     int       `json:"failed"`
+	Blocked    int       `json:"blocked"`
+	Skipped    int       `json:"skipped"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// NewBroadcast makes a draft of the text. The last lines of the form "Title | https://link"
+// become the buttons.
+func NewBroadcast(adminID, adminChatID int64, text, parseMode, photoFileID string) Broadcast {
+	now := time.Now()
+	b := Broadcast{
+		ID:          strconv.FormatInt(now.UnixNano(), 36),
+		AdminID:     adminID,
+		AdminChatID: adminChatID,
+		CreatedAt:   now,
+		Status:      BroadcastDraft,
+		ParseMode:   parseMode,
+		PhotoFileID: photoFileID,
+	}
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for len(lines) > 0 {
+		title, url, ok := strings.Cut(lines[len(lines)-1], "|")
+		title, url = strings.TrimSpace(title), strings.TrimSpace(url)
+		if !ok || title == "" || !(strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")) {
+			break
+		}
+		b.Buttons = append([]BroadcastButton{{Text: title, URL: url}}, b.Buttons...)
+		lines = lines[:len(lines)-1]
+	}
+	b.Text = strings.TrimSpace(strings.Join(lines, "\n"))
+	return b
+}
+
+// Done reports whether the broadcast will not send anything anymore.
+func (b *Broadcast) Done() bool {
+	return b.Status == BroadcastFinished || b.Status == BroadcastCancelled
+}
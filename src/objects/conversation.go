@@ -0,0 +1,66 @@
+package objects
+
+import (
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"tgbot-numerologist/ai"
+)
+
+// conversationContext is the number of first messages always kept in the conversation:
+// the system prompt and the profile with the numbers.
+const conversationContext = 2
+
+// Conversation is the chat with the model started by a prediction, the user continues it
+// by replying to any of its bot messages.
+type Conversation struct {
+	UserID         int64        `json:"user_id"`
+	PredictionType string       `json:"prediction_type"`
+	Messages       []ai.Message `json:"messages"`
+	// MessageIDs are the bot messages with the answers of the model.
+	MessageIDs []int     `json:"message_ids"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewConversation(userID int64, predictionType string, messages []ai.Message, answer string, messageIDs ...int) Conversation {
+	c := Conversation{
+		UserID:         userID,
+		PredictionType: predictionType,
+		Messages:       slices.Clone(messages),
+	}
+	c.AddAnswer(answer, messageIDs...)
+	return c
+}
+
+func (c *Conversation) HasMessage(messageID int) bool {
+	return slices.Contains(c.MessageIDs, messageID)
+}
+
+func (c *Conversation) AddQuestion(question string) {
+	c.Messages = append(c.Messages, ai.Message{Role: ai.RoleUser, Content: question})
+}
+
+// AddAnswer adds the answer of the model, a long answer is sent in several messages.
+func (c *Conversation) AddAnswer(answer string, messageIDs ...int) {
+	c.Messages = append(c.Messages, ai.Message{Role: ai.RoleAssistant, Content: answer})
+	c.MessageIDs = append(c.MessageIDs, messageIDs...)
+	c.UpdatedAt = time.Now()
+}
+
+// EstimateTokens roughly estimates the tokens of the messages, about four characters per token.
+func EstimateTokens(messages []ai.Message) int {
+	tokens := 0
+	for _, message := range messages {
+		tokens += utf8.RuneCountInString(message.Content)/4 + 4
+	}
+	return tokens
+}
+
+// Trim drops the oldest question and answer pairs until the conversation fits into budget tokens.
+// The context and the last question are always kept, and the user and assistant messages keep alternating.
+func (c *Conversation) Trim(budget int) {
+	for EstimateTokens(c.Messages) > budget && len(c.Messages) > conversationContext+3 {
+		c.Messages = slices.Delete(c.Messages, conversationContext, conversationContext+2)
+	}
+}
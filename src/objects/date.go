@@ -0,0 +1,175 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"tgbot-numerologist/utils"
+)
+
+var ErrWrongDate = errors.New("wrong date format")
+
+// AmbiguousDateError is returned for a date like 05/03/1990 which may be read both as
+// the 5th of March and the 3rd of May.
+type AmbiguousDateError struct {
+	First, Second time.Time
+}
+
+func (e *AmbiguousDateError) Error() string {
+	return fmt.Sprintf("ambiguous date: %s or %s", e.First.Format(time.DateOnly), e.Second.Format(time.DateOnly))
+}
+
+// monthNames are the Russian and English names of the months, a name may also be shortened to 3 letters.
+var monthNames = [12][]string{
+	{"январь", "января", "january"},
+	{"февраль", "февраля", "february"},
+	{"март", "марта", "march"},
+	{"апрель", "апреля", "april"},
+	{"май", "мая", "may"},
+	{"июнь", "июня", "june"},
+	{"июль", "июля", "july"},
+	{"август", "августа", "august"},
+	{"сентябрь", "сентября", "september"},
+	{"октябрь", "октября", "october"},
+	{"ноябрь", "ноября", "november"},
+	{"декабрь", "декабря", "december"},
+}
+
+// yearWords may follow the year, e.g. "5 марта 1990 г.".
+var yearWords = map[string]bool{"г": true, "год": true, "года": true}
+
+// ParseDate reads a date written by the user:
+//
+//	05.03.1990, 5.3.1990, 5.3.90, 05-03-1990   day first
+//	1990-03-05, 1990.03.05, 1990/03/05         year first
+//	05/03/1990                                 day or month first, ambiguous when both are up to 12
+//	5 марта 1990, 5 мар. 1990 г., March 5, 1990, 5th of March 1990
+//
+// Returns ErrWrongDate or *AmbiguousDateError.
+func ParseDate(text string) (time.Time, error) {
+	date, err := parseDate(strings.ToLower(strings.TrimSpace(text)))
+	if err != nil {
+		utils.Log("error while parse date %s: %s", text, err.Error())
+	}
+	return date, err
+}
+
+func parseDate(text string) (time.Time, error) {
+	tokens := strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == '.' || r == '-' || r == '/'
+	})
+	var words, numbers []string
+	for _, token := range tokens {
+		if unicode.IsDigit([]rune(token)[0]) {
+			numbers = append(numbers, strings.TrimRight(token, "stndrh"))
+			continue
+		}
+		if !yearWords[token] && token != "of" {
+			words = append(words, token)
+		}
+	}
+	if len(words) == 1 && len(numbers) == 2 {
+		month, ok := findMonth(words[0])
+		if !ok {
+			return time.Time{}, ErrWrongDate
+		}
+		// The year is the number of 4 digits, the other one is the day whatever the order.
+		day, year := numbers[0], numbers[1]
+		if len(day) == 4 {
+			day, year = year, day
+		}
+		return makeDate(year, int(month), day)
+	}
+	if len(words) > 0 || len(numbers) != 3 {
+		return time.Time{}, ErrWrongDate
+	}
+
+	if len(numbers[0]) == 4 {
+		month, err := strconv.Atoi(numbers[1])
+		if err != nil {
+			return time.Time{}, ErrWrongDate
+		}
+		return makeDate(numbers[0], month, numbers[2])
+	}
+	first, err1 := strconv.Atoi(numbers[0])
+	second, err2 := strconv.Atoi(numbers[1])
+	if err1 != nil || err2 != nil {
+		return time.Time{}, ErrWrongDate
+	}
+	// The slash is used both in the day first and in the American month first order.
+	if strings.Contains(text, "/") && first <= 12 && second <= 12 && first != second {
+		dayFirst, err := makeDate(numbers[2], second, numbers[0])
+		if err != nil {
+			return time.Time{}, err
+		}
+		monthFirst, err := makeDate(numbers[2], first, numbers[1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Time{}, &AmbiguousDateError{First: dayFirst, Second: monthFirst}
+	}
+	if strings.Contains(text, "/") && second > 12 {
+		return makeDate(numbers[2], first, numbers[1])
+	}
+	return makeDate(numbers[2], second, numbers[0])
+}
+
+func findMonth(word string) (time.Month, bool) {
+	if len([]rune(word)) < 3 {
+		return 0, false
+	}
+	for i, names := range monthNames {
+		for _, name := range names {
+			if strings.HasPrefix(name, word) {
+				return time.Month(i + 1), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// makeDate checks the day exists in the month, a year of 2 digits is taken in the last hundred years.
+func makeDate(yearText string, month int, dayText string) (time.Time, error) {
+	year, err := strconv.Atoi(yearText)
+	if err != nil || (len(yearText) != 2 && len(yearText) != 4) {
+		return time.Time{}, ErrWrongDate
+	}
+	day, err := strconv.Atoi(dayText)
+	if err != nil || len(dayText) > 2 {
+		return time.Time{}, ErrWrongDate
+	}
+	if len(yearText) == 2 {
+		now := time.Now().Year()
+		year += now / 100 * 100
+		if year > now {
+			year -= 100
+		}
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if month < 1 || month > 12 || day < 1 || date.Day() != day {
+		return time.Time{}, ErrWrongDate
+	}
+	return date, nil
+}
+
+var ErrWrongTime = errors.New("wrong time format")
+
+// ParseClock reads a time of day written as 9:05, 09.05 or 0905 and returns it as 09:05.
+func ParseClock(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	hours, minutes, ok := strings.Cut(strings.NewReplacer(".", ":", " ", ":", "-", ":").Replace(text), ":")
+	if !ok && len(text) == 4 {
+		hours, minutes = text[:2], text[2:]
+	}
+	h, err1 := strconv.Atoi(hours)
+	m, err2 := strconv.Atoi(minutes)
+	if err1 != nil || err2 != nil || len(hours) > 2 || len(minutes) != 2 || h < 0 || h > 23 || m < 0 || m > 59 {
+		utils.Log("error while parse time %s", text)
+		return "", ErrWrongTime
+	}
+	return fmt.Sprintf("%02d:%02d", h, m), nil
+}
@@ -0,0 +1,122 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"tgbot-numerologist/utils"
+)
+
+func TestMain(m *testing.M) {
+	utils.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		err  error
+	}{
+		{"05.03.1990", day(1990, time.March, 5), nil},
+		{"5.3.1990", day(1990, time.March, 5), nil},
+		{"5.3.90", day(1990, time.March, 5), nil},
+		{"05-03-1990", day(1990, time.March, 5), nil},
+		{" 05.03.1990 ", day(1990, time.March, 5), nil},
+		{"1990-03-05", day(1990, time.March, 5), nil},
+		{"1990.03.05", day(1990, time.March, 5), nil},
+		{"1990/03/05", day(1990, time.March, 5), nil},
+		{"05/05/1990", day(1990, time.May, 5), nil},
+		{"25/03/1990", day(1990, time.March, 25), nil},
+		{"03/25/1990", day(1990, time.March, 25), nil},
+		{"5 марта 1990", day(1990, time.March, 5), nil},
+		{"5 мар. 1990 г.", day(1990, time.March, 5), nil},
+		{"1990 5 марта", day(1990, time.March, 5), nil},
+		{"March 5, 1990", day(1990, time.March, 5), nil},
+		{"5th of March 1990", day(1990, time.March, 5), nil},
+		{"29.02.2000", day(2000, time.February, 29), nil},
+		{"31.02.1990", time.Time{}, ErrWrongDate},
+		{"29.02.1990", time.Time{}, ErrWrongDate},
+		{"32.01.1990", time.Time{}, ErrWrongDate},
+		{"13.13.1990", time.Time{}, ErrWrongDate},
+		{"1990-13-01", time.Time{}, ErrWrongDate},
+		{"5.3.199", time.Time{}, ErrWrongDate},
+		{"5 ма 1990", time.Time{}, ErrWrongDate},
+		{"5 марта", time.Time{}, ErrWrongDate},
+		{"вчера", time.Time{}, ErrWrongDate},
+		{"", time.Time{}, ErrWrongDate},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseDate(%q) error = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%q) = %s, want %s", tt.in, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestParseDateTwoDigitYear(t *testing.T) {
+	year := time.Now().Year() % 100
+	got, err := ParseDate(fmt.Sprintf("01.01.%02d", year))
+	if err != nil || got.Year() != time.Now().Year() {
+		t.Errorf("the current year is read as %d: %v", got.Year(), err)
+	}
+	got, err = ParseDate(fmt.Sprintf("01.01.%02d", (year+1)%100))
+	if err != nil || got.Year() != time.Now().Year()-99 {
+		t.Errorf("the next year is read as %d: %v", got.Year(), err)
+	}
+}
+
+func TestParseDateAmbiguous(t *testing.T) {
+	_, err := ParseDate("05/03/1990")
+	var ambiguous *AmbiguousDateError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("ParseDate(05/03/1990) error = %v, want ambiguous", err)
+	}
+	if !ambiguous.First.Equal(day(1990, time.March, 5)) || !ambiguous.Second.Equal(day(1990, time.May, 3)) {
+		t.Errorf("got %s", ambiguous)
+	}
+
+	if _, err := ParseDate("05/31/1990"); err != nil {
+		t.Errorf("month first date with the day over 12 is not ambiguous: %v", err)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      error
+	}{
+		{"9:05", "09:05", nil},
+		{"09:05", "09:05", nil},
+		{"09.05", "09:05", nil},
+		{"0905", "09:05", nil},
+		{"9 05", "09:05", nil},
+		{"23-59", "23:59", nil},
+		{" 0:00 ", "00:00", nil},
+		{"24:00", "", ErrWrongTime},
+		{"12:60", "", ErrWrongTime},
+		{"9:5", "", ErrWrongTime},
+		{"123:00", "", ErrWrongTime},
+		{"905", "", ErrWrongTime},
+		{"noon", "", ErrWrongTime},
+		{"", "", ErrWrongTime},
+	}
+	for _, tt := range tests {
+		got, err := ParseClock(tt.in)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("ParseClock(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
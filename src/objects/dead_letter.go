@@ -0,0 +1,15 @@
+package objects
+
+import "time"
+
+// DeadLetter is a message which could not be delivered to the user after all the retries.
+type DeadLetter struct {
+	CreatedAt time.Time `json:"created_at"`
+	ChatID    int64     `json:"chat_id"`
+	// Method is the Telegram API method, e.g. sendMessage or editMessageText.
+	Method    string `json:"method"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+	Error     string `json:"error"`
+	Attempts  int    `json:"attempts"`
+}
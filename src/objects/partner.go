@@ -0,0 +1,94 @@
+package objects
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Partner is a person saved by the user for compatibility readings.
+type Partner struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name" validate:"min=1,max=64,script=latin|cyrillic"`
+	Surname   string    `json:"surname,omitempty" validate:"max=64,script=latin|cyrillic"`
+	BirthDate time.Time `json:"birth_date" validate:"past,maxage=120"`
+	// UserID is set when the partner is a bot user who shared the profile. Only the name is kept
+	// for the button, the data is read from that profile on every reading.
+	UserID int64 `json:"user_id,omitempty"`
+	// ShareToken is the token of the link the partner was added with, the partner is
+	// available while the owner shares the profile with the same token.
+	ShareToken string `json:"share_token,omitempty"`
+}
+
+// NewLinkedPartner refers to the profile of the owner of the share link.
+func NewLinkedPartner(owner *Profile) Partner {
+	partner := NewPartner(owner.Name, "", time.Time{})
+	partner.UserID = owner.UserID
+	partner.ShareToken = owner.ShareToken
+	return partner
+}
+
+// SharedBy reports whether the owner still shares the profile with the linked partner
+// by the link the partner was added with.
+func (p Partner) SharedBy(owner *Profile) bool {
+	return owner.UserID == p.UserID && owner.ShareToken != "" && p.ShareToken == owner.ShareToken
+}
+
+func NewPartner(name, surname string, birthDate time.Time) Partner {
+	return Partner{
+		ID:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		Name:      name,
+		Surname:   surname,
+		BirthDate: birthDate,
+	}
+}
+
+// Title is shown on the partner buttons.
+func (p Partner) Title() string {
+	title := p.Name
+	if p.Surname != "" {
+		title += " " + p.Surname
+	}
+	if p.BirthDate.IsZero() {
+		return title
+	}
+	return title + ", " + p.BirthDate.Format("02.01.2006")
+}
+
+func (p *Profile) FindPartner(id string) (Partner, bool) {
+	for _, partner := range p.Partners {
+		if partner.ID == id {
+			return partner, true
+		}
+	}
+	return Partner{}, false
+}
+
+// SavePartner adds the partner or replaces the one with the same id or linked user.
+func (p *Profile) SavePartner(partner Partner) {
+	for i, saved := range p.Partners {
+		if saved.ID == partner.ID || (partner.UserID != 0 && saved.UserID == partner.UserID) {
+			partner.ID = saved.ID
+			p.Partners[i] = partner
+			return
+		}
+	}
+	p.Partners = append(p.Partners, partner)
+}
+
+func (p *Profile) DeletePartner(id string) {
+	for i, partner := range p.Partners {
+		if partner.ID == id {
+			p.Partners = append(p.Partners[:i], p.Partners[i+1:]...)
+			return
+		}
+	}
+}
+
+// NewShareToken generates the secret part of the link the user shares to be added as a partner.
+func NewShareToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
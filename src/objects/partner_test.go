@@ -0,0 +1,30 @@
+package objects
+
+import "testing"
+
+func TestPartnerSharedBy(t *testing.T) {
+	owner := &Profile{UserID: 7, Name: "Анна", ShareToken: "abc"}
+	linked := NewLinkedPartner(owner)
+	tests := []struct {
+		name    string
+		partner Partner
+		owner   Profile
+		want    bool
+	}{
+		{"shared", linked, *owner, true},
+		{"sharing is off", linked, Profile{UserID: 7}, false},
+		{"link is replaced", linked, Profile{UserID: 7, ShareToken: "def"}, false},
+		{"other user", linked, Profile{UserID: 8, ShareToken: "abc"}, false},
+		{"linked without token", Partner{UserID: 7}, Profile{UserID: 7, ShareToken: "def"}, false},
+		{"linked without token, sharing is off", Partner{UserID: 7}, Profile{UserID: 7}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.partner.SharedBy(&tt.owner); got != tt.want {
+			t.Errorf("%s: SharedBy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	// Only the name of the owner is kept in the partner.
+	if linked.Name != "Анна" || !linked.BirthDate.IsZero() || linked.Title() != "Анна" {
+		t.Errorf("linked partner %+v, title %q", linked, linked.Title())
+	}
+}
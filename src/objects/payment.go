@@ -0,0 +1,81 @@
+package objects
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/i18n"
+)
+
+// StarsCurrency is the currency code of Telegram Stars.
+const StarsCurrency = "XTR"
+
+// QuotaPackage is a number of predictions sold for a price in Telegram Stars.
+type QuotaPackage struct {
+	ID    string
+	Quota int64
+	Stars int
+}
+
+// ParsePackages parses a comma separated list of packages in the form id:quota:stars, e.g. "small:1:50,big:10:350".
+func ParsePackages(s string) ([]QuotaPackage, error) {
+	var packages []QuotaPackage
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("package %q must be in the form id:quota:stars", item)
+		}
+		quota, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || quota <= 0 {
+			return nil, fmt.Errorf("package %q has wrong quota", item)
+		}
+		stars, err := strconv.Atoi(parts[2])
+		if err != nil || stars <= 0 {
+			return nil, fmt.Errorf("package %q has wrong price", item)
+		}
+		packages = append(packages, QuotaPackage{ID: parts[0], Quota: quota, Stars: stars})
+	}
+	return packages, nil
+}
+
+// Title is shown on the buy button and in the invoice.
+func (p QuotaPackage) Title(lang string) string {
+	return i18n.T(lang, "payment.package", i18n.Plural(lang, "predictions", p.Quota), p.Stars)
+}
+
+// InvoicePayload describes the purchase, so the quota is credited as it was at the time of the invoice.
+func (p QuotaPackage) InvoicePayload() string {
+	return fmt.Sprintf("quota:%s:%d", p.ID, p.Quota)
+}
+
+// ParseInvoicePayload returns the package id and quota from the invoice payload.
+func ParseInvoicePayload(payload string) (string, int64, error) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 || parts[0] != "quota" {
+		return "", 0, fmt.Errorf("unknown invoice payload %q", payload)
+	}
+	quota, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || quota <= 0 {
+		return "", 0, fmt.Errorf("wrong quota in invoice payload %q", payload)
+	}
+	return parts[1], quota, nil
+}
+
+// Payment is a record of the payment ledger.
+type Payment struct {
+	// ChargeID is the Telegram payment charge id, it is unique for every payment.
+	ChargeID         string    `json:"charge_id"`
+	ProviderChargeID string    `json:"provider_charge_id"`
+	UserID           int64     `json:"user_id"`
+	PackageID        string    `json:"package_id"`
+	Quota            int64     `json:"quota"`
+	Amount           int       `json:"amount"`
+	Currency         string    `json:"currency"`
+	CreatedAt        time.Time `json:"created_at"`
+}
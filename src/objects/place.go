@@ -0,0 +1,59 @@
+package objects
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"tgbot-numerologist/places"
+)
+
+var ErrUnknownPlace = errors.New("unknown place")
+
+// AmbiguousPlaceError is returned for a city name found in several countries.
+type AmbiguousPlaceError struct {
+	Cities []places.City
+}
+
+func (e *AmbiguousPlaceError) Error() string {
+	return "ambiguous place: " + e.Cities[0].NameEN
+}
+
+// Names lists the cities in the language, one per line.
+func (e *AmbiguousPlaceError) Names(lang string) string {
+	var names []string
+	for _, city := range e.Cities {
+		names = append(names, city.Name(lang))
+	}
+	return strings.Join(names, "\n")
+}
+
+// maxPlaceName is the length of the name of a place missing from the dataset.
+const maxPlaceName = 64
+
+// ParsePlace finds the city of birth in the bundled dataset. A place missing from it
+// is entered with the time zone after the last comma, e.g. "Урюпинск, UTC+3".
+// Returns ErrUnknownPlace or *AmbiguousPlaceError.
+func ParsePlace(text string) (*places.City, error) {
+	found := places.Find(text)
+	switch len(found) {
+	case 0:
+		return parseCustomPlace(text)
+	case 1:
+		return &found[0], nil
+	}
+	return nil, &AmbiguousPlaceError{Cities: found}
+}
+
+func parseCustomPlace(text string) (*places.City, error) {
+	i := strings.LastIndex(text, ",")
+	if i < 0 {
+		return nil, ErrUnknownPlace
+	}
+	name := strings.Join(strings.Fields(text[:i]), " ")
+	timezone, ok := places.ParseTimezone(text[i+1:])
+	if !ok || name == "" || utf8.RuneCountInString(name) > maxPlaceName {
+		return nil, ErrUnknownPlace
+	}
+	return &places.City{NameRU: name, NameEN: name, Timezone: timezone}, nil
+}
@@ -0,0 +1,48 @@
+package objects
+
+import (
+	"errors"
+	"testing"
+
+	"tgbot-numerologist/i18n"
+)
+
+func TestParsePlace(t *testing.T) {
+	tests := []struct {
+		text     string
+		name     string
+		timezone string
+		err      error
+	}{
+		{"Москва", "Москва, Россия", "Europe/Moscow", nil},
+		{"брест, беларусь", "Брест, Беларусь", "Europe/Minsk", nil},
+		{"Урюпинск", "", "", ErrUnknownPlace},
+		{"Урюпинск, Europe/Moscow", "Урюпинск", "Europe/Moscow", nil},
+		{"Урюпинск , utc+3", "Урюпинск", "Etc/GMT-3", nil},
+		{"Smallville, Kansas, GMT-06:00", "Smallville, Kansas", "Etc/GMT+6", nil},
+		{"Smallville, UTC", "Smallville", "UTC", nil},
+		{"Smallville, +0", "Smallville", "UTC", nil},
+		{"Smallville, UTC+30", "", "", ErrUnknownPlace},
+		{"Smallville, Local", "", "", ErrUnknownPlace},
+		{"Smallville, Kansas", "", "", ErrUnknownPlace},
+		{", UTC+3", "", "", ErrUnknownPlace},
+	}
+	for _, tt := range tests {
+		city, err := ParsePlace(tt.text)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParsePlace(%q) error = %v, want %v", tt.text, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if city.Name(i18n.Russian) != tt.name || city.Timezone != tt.timezone {
+			t.Errorf("ParsePlace(%q) = %q %s, want %q %s", tt.text, city.Name(i18n.Russian), city.Timezone, tt.name, tt.timezone)
+		}
+	}
+
+	var ambiguous *AmbiguousPlaceError
+	if _, err := ParsePlace("Брест"); !errors.As(err, &ambiguous) {
+		t.Errorf("ParsePlace of an ambiguous city = %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package objects
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+const PredictionGeneral = "general"
+
+// Prediction is an AI answer saved to the history of the user.
+type Prediction struct {
+	ID          string    `json:"id"`
+	UserID      int64     `json:"user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Type        string    `json:"type"`
+	ProfileHash string    `json:"profile_hash"`
+	Model       string    `json:"model"`
+	Text        string    `json:"text"`
+}
+
+func NewPrediction(userID int64, predictionType, profileHash, model, text string) Prediction {
+	now := time.Now()
+	return Prediction{
+		// Short enough for callback data and unique for a single user.
+		ID:          strconv.FormatInt(now.UnixNano(), 36),
+		UserID:      userID,
+		CreatedAt:   now,
+		Type:        predictionType,
+		ProfileHash: profileHash,
+		Model:       model,
+		Text:        text,
+	}
+}
+
+// Hash identifies the state of the profile the prediction was made for.
+func (p *Profile) Hash() string {
+	data, _ := p.ProfileAIMessage()
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:8])
+}
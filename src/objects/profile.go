@@ -1,48 +1,84 @@
 package objects
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
 	"time"
 
-	"tgbot-numerologist/utils"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/places"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type Profile struct {
-	Username     string    `type:"internal" json:"username"`
-	ChatID       int64     `type:"internal" json:"chat_id"`
-	Predictions  int64     `type:"internal" json:"predictions"`
-	Quote        int64     `type:"internal" json:"quote"`
-	EditingField string    `type:"internal" json:"editing_field"`
-	Name         string    `type:"required" json:"name"`
-	Surname      string    `type:"optional" json:"surname"`
-	BirthDate    time.Time `type:"required" json:"birth_date"`
-	Bio          string    `type:"optional" json:"bio"`
-	WorkPlace    string    `type:"optional" json:"work_place"`
-	StudyPlace   string    `type:"optional" json:"study_place"`
-	Hobby        string    `type:"optional" json:"hobby"`
+	UserID   int64  `type:"internal" json:"user_id"`
+	Username string `type:"internal" json:"username"`
+	ChatID   int64  `type:"internal" json:"chat_id"`
+	// State is the dialog step the user is answering, StateArg is its parameter, e.g. the prediction type.
+	State     string    `type:"internal" json:"state,omitempty"`
+	StateArg  string    `type:"internal" json:"state_arg,omitempty"`
+	Language  string    `type:"internal" json:"language"`
+	Name      string    `type:"required" json:"name" validate:"min=1,max=64,script=latin|cyrillic"`
+	Surname   string    `type:"optional" json:"surname" validate:"max=64,script=latin|cyrillic"`
+	BirthDate time.Time `type:"required" json:"birth_date" validate:"past,minage=5,maxage=120"`
+	// BirthTime is the local time of birth as HH:MM at BirthPlace.
+	BirthTime  string       `type:"optional" json:"birth_time,omitempty"`
+	BirthPlace *places.City `type:"optional" json:"birth_place,omitempty"`
+	Bio        string       `type:"optional" json:"bio" validate:"max=1000,text"`
+	WorkPlace  string       `type:"optional" json:"work_place" validate:"max=200,text"`
+	StudyPlace string       `type:"optional" json:"study_place" validate:"max=200,text"`
+	Hobby      string       `type:"optional" json:"hobby" validate:"max=300,text"`
+	// ShareToken allows other users to add this profile as a partner, empty when sharing is off.
+	ShareToken string    `json:"share_token,omitempty"`
+	Partners   []Partner `json:"partners,omitempty"`
+	// PartnerDraft keeps the partner being entered by the user.
+	PartnerDraft *Partner `json:"partner_draft,omitempty"`
+	// Subscription is set when the user receives the daily forecast.
+	Subscription *Subscription `json:"subscription,omitempty"`
+	// Banned users are ignored by the bot, set by the admins.
+	Banned bool `json:"banned,omitempty"`
+	// Inactive users blocked the bot, they get no broadcasts and forecasts until they write again.
+	Inactive bool `json:"inactive,omitempty"`
 }
 
-func NewProfile(username string, chatId int64) Profile {
-	return Profile{Username: username, ChatID: chatId, Quote: 3}
+// DefaultTimezone is the timezone of the users who set neither a subscription nor a birthplace.
+const DefaultTimezone = "Europe/Moscow"
+
+// DefaultQuota is the number of free predictions of a new user.
+const DefaultQuota = 3
+
+// QuotaUnit is the number of parts a prediction of the quota is divided into for fractional costs.
+const QuotaUnit = 1000
+
+func NewProfile(userID int64, username string, chatId int64) Profile {
+	return Profile{UserID: userID, Username: username, ChatID: chatId}
 }
 
-func ParseDate(birthdate string) (time.Time, error) {
-	parsed, err := time.Parse("02.01.2006", birthdate)
+// Location returns the timezone of the user: the one of the subscription,
+// else the one of the birthplace, else DefaultTimezone.
+func (p *Profile) Location() *time.Location {
+	if p.Subscription != nil {
+		return p.Subscription.Location()
+	}
+	if p.BirthPlace != nil {
+		if loc, err := time.LoadLocation(p.BirthPlace.Timezone); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(DefaultTimezone)
 	if err != nil {
-		utils.Log("error while parse date %s: %s", birthdate, err.Error())
-		return parsed, errors.New(utils.ErrWrongTimeFormat)
+		return time.UTC
 	}
-	return parsed, nil
+	return loc
 }
 
 func (p *Profile) ResetProfile() {
 	p.Name = ""
 	p.Surname = ""
 	p.BirthDate = time.Time{}
+	p.BirthTime = ""
+	p.BirthPlace = nil
 	p.Bio = ""
 	p.WorkPlace = ""
 	p.StudyPlace = ""
@@ -50,66 +86,38 @@ func (p *Profile) ResetProfile() {
 }
 
 func (p *Profile) FormatProfileMessage() string {
-	requiredFields := []string{"Name", "BirthDate"}
-	fieldStatus := func(field string) string {
-		for _, requiredField := range requiredFields {
-			if field == requiredField {
-				return "_(обязательно)_"
-			}
-		}
-		return ""
-	}
-	formatRow := func(key, value, field string) string {
+	lang := p.Language
+	res := i18n.T(lang, "profile.title")
+	for _, field := range ProfileFields {
+		value := field.Get(p)
 		if value == "" {
 			value = "-"
 		}
-		res := key + ": " + value
-		status := fieldStatus(field)
-		if len(status) != 0 && value == "-" {
-			res += " " + status
+		res += i18n.T(lang, "profile."+field.ID) + ": " + value
+		if field.Required && value == "-" {
+			res += " " + i18n.T(lang, "profile.required")
 		}
 		res += "\n"
-		return res
-	}
-	res := "*Ваш профиль*:\n"
-	res += formatRow("Имя", p.Name, "Name")
-	res += formatRow("Фамилия", p.Surname, "Surname")
-	if p.BirthDate.IsZero() {
-		res += formatRow("Дата рождения", "", "BirthDate")
-	} else {
-		res += formatRow("Дата рождения", p.BirthDate.Format("02.01.2006"), "BirthDate")
 	}
-	res += formatRow("Место работы", p.WorkPlace, "WorkPlace")
-	res += formatRow("Место учёбы", p.StudyPlace, "StudyPlace")
-	res += formatRow("Хобби", p.Hobby, "Hobby")
-	res += formatRow("Биография", p.Bio, "Bio")
 	return res
 }
 
 func (p *Profile) GetKeyboard() tgbotapi.InlineKeyboardMarkup {
-	buttons := []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("Изменить имя", "edit_name"),
-		tgbotapi.NewInlineKeyboardButtonData("Изменить фамилию", "edit_surname"),
-		tgbotapi.NewInlineKeyboardButtonData("Изменить дату рождения", "edit_birthdate"),
-		tgbotapi.NewInlineKeyboardButtonData("Изменить место работы", "edit_workplace"),
-		tgbotapi.NewInlineKeyboardButtonData("Изменить место учёбы", "edit_studyplace"),
-		tgbotapi.NewInlineKeyboardButtonData("Изменить хобби", "edit_hobby"),
-		tgbotapi.NewInlineKeyboardButtonData("Изменить биографию", "edit_bio"),
-	}
-
 	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for _, btn := range buttons {
-		row := tgbotapi.NewInlineKeyboardRow(btn)
-		keyboard = append(keyboard, row)
+	for _, field := range ProfileFields {
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(p.Language, "profile.edit_"+field.ID), EditFieldPrefix+field.ID),
+		))
 	}
-
 	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
 }
 
-func (p *Profile) GetPaymentKeyboard() tgbotapi.InlineKeyboardMarkup {
-	buttons := []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("Увеличить квоту", "pay"),
+func (p *Profile) GetPaymentKeyboard(packages []QuotaPackage) tgbotapi.InlineKeyboardMarkup {
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, pkg := range packages {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(pkg.Title(p.Language), "buy:"+pkg.ID))
 	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(i18n.T(p.Language, "payment.history_button"), "payments"))
 
 	var keyboard [][]tgbotapi.InlineKeyboardButton
 	for _, btn := range buttons {
@@ -136,7 +144,9 @@ func (p *Profile) ProfileAIMessage() (string, error) {
 		}
 
 		var strValue string
-		if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
+		if fieldValue.Kind() == reflect.Pointer && fieldValue.IsNil() {
+			strValue = ""
+		} else if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
 			if tt, ok := fieldValue.Interface().(time.Time); ok && !tt.IsZero() {
 				strValue = tt.Format("02.01.2006")
 			} else {
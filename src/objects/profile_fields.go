@@ -0,0 +1,103 @@
+package objects
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/places"
+)
+
+// EditFieldPrefix starts the callback data of the buttons editing a profile field.
+const EditFieldPrefix = "edit_"
+
+// ProfileField is a field of the profile filled in by the user. Its messages are "profile.<ID>"
+// for the label, "profile.edit_<ID>" for the button and "profile.enter_<ID>" for the question.
+type ProfileField struct {
+	ID string
+	// Field is the name of the Profile field, its validate tag is checked on Set.
+	Field    string
+	Required bool
+	// Parse converts the text to the value of the field, by default a date is parsed
+	// with ParseDate and a string is trimmed.
+	Parse func(text string) (any, error)
+}
+
+// ProfileFields are listed in the order they are shown in the profile.
+var ProfileFields = []ProfileField{
+	{ID: "name", Field: "Name", Required: true},
+	{ID: "surname", Field: "Surname"},
+	{ID: "birthdate", Field: "BirthDate", Required: true},
+	{ID: "birthtime", Field: "BirthTime", Parse: func(text string) (any, error) { return ParseClock(text) }},
+	{ID: "birthplace", Field: "BirthPlace", Parse: func(text string) (any, error) { return ParsePlace(text) }},
+	{ID: "workplace", Field: "WorkPlace"},
+	{ID: "studyplace", Field: "StudyPlace"},
+	{ID: "hobby", Field: "Hobby"},
+	{ID: "bio", Field: "Bio"},
+}
+
+func FindProfileField(id string) (ProfileField, bool) {
+	for _, field := range ProfileFields {
+		if field.ID == id {
+			return field, true
+		}
+	}
+	return ProfileField{}, false
+}
+
+// Get returns the value of the field as shown to the user, empty when it is not filled.
+func (f ProfileField) Get(p *Profile) string {
+	switch value := reflect.ValueOf(p).Elem().FieldByName(f.Field).Interface().(type) {
+	case *places.City:
+		if value == nil {
+			return ""
+		}
+		return value.Name(p.Language)
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format("02.01.2006")
+	case string:
+		return value
+	}
+	return ""
+}
+
+// IsDate reports whether the field keeps a date.
+func (f ProfileField) IsDate() bool {
+	field, _ := reflect.TypeOf(Profile{}).FieldByName(f.Field)
+	return field.Type == reflect.TypeOf(time.Time{})
+}
+
+// Set parses and validates the text entered by the user and saves it to the profile.
+// Returns the error of Parse or *ValidationError for a wrong text.
+func (f ProfileField) Set(p *Profile, text string) error {
+	target := reflect.ValueOf(p).Elem().FieldByName(f.Field)
+	parse := f.Parse
+	if parse == nil && f.IsDate() {
+		parse = func(text string) (any, error) { return ParseDate(text) }
+	}
+	var value any = strings.TrimSpace(text)
+	if parse != nil {
+		var err error
+		if value, err = parse(text); err != nil {
+			return err
+		}
+	}
+	if err := Validate(p, f.Field, value); err != nil {
+		return err
+	}
+	target.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// IsFilled reports whether all the required fields are filled.
+func (p *Profile) IsFilled() bool {
+	for _, field := range ProfileFields {
+		if field.Required && field.Get(p) == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package objects
+
+import (
+	"testing"
+
+	"tgbot-numerologist/places"
+)
+
+func TestProfileLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		want    string
+	}{
+		{"default", Profile{}, DefaultTimezone},
+		{"birthplace", Profile{BirthPlace: &places.City{Timezone: "Asia/Omsk"}}, "Asia/Omsk"},
+		{"unknown birthplace timezone", Profile{BirthPlace: &places.City{Timezone: "Nowhere/City"}}, DefaultTimezone},
+		{"subscription", Profile{
+			BirthPlace:   &places.City{Timezone: "Asia/Omsk"},
+			Subscription: &Subscription{Timezone: "America/New_York"},
+		}, "America/New_York"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.Location().String(); got != tt.want {
+				t.Errorf("Location = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package objects
+
+import (
+	"fmt"
+	"time"
+
+	"tgbot-numerologist/i18n"
+)
+
+const subscriptionDateFormat = "2006-01-02"
+
+// Subscription is the daily forecast delivered at Hour:Minute in Timezone.
+type Subscription struct {
+	Hour     int    `json:"hour"`
+	Minute   int    `json:"minute"`
+	Timezone string `json:"timezone"`
+	// LastSent is the local date of the last delivered forecast, it prevents sending twice a day.
+	LastSent string `json:"last_sent,omitempty"`
+	// Failures is the number of the failed attempts to deliver the forecast which is due.
+	Failures int `json:"failures,omitempty"`
+}
+
+// NewSubscription creates the subscription, the forecast of today is skipped when its time has already passed.
+func NewSubscription(hour, minute int, timezone string, now time.Time) (Subscription, error) {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return Subscription{}, fmt.Errorf("wrong time %d:%d", hour, minute)
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return Subscription{}, err
+	}
+	s := Subscription{Hour: hour, Minute: minute, Timezone: timezone}
+	if date, ok := s.Due(now); ok {
+		s.LastSent = date
+	}
+	return s, nil
+}
+
+func (s Subscription) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Due reports whether the forecast of the local date of now has to be sent and returns this date.
+func (s Subscription) Due(now time.Time) (string, bool) {
+	local := now.In(s.Location())
+	date := local.Format(subscriptionDateFormat)
+	if s.LastSent == date {
+		return date, false
+	}
+	return date, local.Hour()*60+local.Minute() >= s.Hour*60+s.Minute
+}
+
+// Next returns the time the next forecast becomes due: the time of today when the forecast of today is not sent yet,
+// it may be already passed, and the time of tomorrow otherwise.
+func (s Subscription) Next(now time.Time) time.Time {
+	local := now.In(s.Location())
+	day := local.Day()
+	if s.LastSent == local.Format(subscriptionDateFormat) {
+		day++
+	}
+	return time.Date(local.Year(), local.Month(), day, s.Hour, s.Minute, 0, 0, local.Location())
+}
+
+func (s Subscription) Title(lang string) string {
+	return i18n.T(lang, "subscribe.title", s.Hour, s.Minute, s.Timezone)
+}
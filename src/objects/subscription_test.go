@@ -0,0 +1,43 @@
+package objects
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscriptionNext(t *testing.T) {
+	utc := func(month time.Month, d, hour int) time.Time {
+		return time.Date(2026, month, d, hour, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		timezone string
+		lastSent string
+		now      time.Time
+		want     time.Time
+	}{
+		{"later today", "UTC", "2026-10-17", utc(time.October, 18, 8), utc(time.October, 18, 9)},
+		{"missed today", "UTC", "2026-10-17", utc(time.October, 18, 10), utc(time.October, 18, 9)},
+		{"never sent", "UTC", "", utc(time.October, 18, 10), utc(time.October, 18, 9)},
+		{"sent today", "UTC", "2026-10-18", utc(time.October, 18, 10), utc(time.October, 19, 9)},
+		{"end of month", "UTC", "2026-10-31", utc(time.October, 31, 10), utc(time.November, 1, 9)},
+		// 22:00 UTC is already the 19th in Moscow.
+		{"local date", "Europe/Moscow", "2026-10-18", utc(time.October, 18, 22), utc(time.October, 19, 6)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Subscription{Hour: 9, Timezone: tt.timezone, LastSent: tt.lastSent}
+			next := s.Next(tt.now)
+			if !next.Equal(tt.want) {
+				t.Fatalf("Next = %s, want %s", next.UTC(), tt.want)
+			}
+			// The forecast is due from the time returned by Next.
+			if _, ok := s.Due(next); !ok {
+				t.Errorf("forecast is not due at %s", next.UTC())
+			}
+			if _, ok := s.Due(next.Add(-time.Minute)); ok && next.After(tt.now) {
+				t.Errorf("forecast is due before %s", next.UTC())
+			}
+		})
+	}
+}
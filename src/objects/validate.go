@@ -0,0 +1,146 @@
+package objects
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValidationError describes why the value entered by the user was rejected,
+// Message is the ID of the localized message formatted with Args.
+type ValidationError struct {
+	Message string
+	Args    []any
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %s %v", e.Message, e.Args)
+}
+
+func invalid(message string, args ...any) *ValidationError {
+	return &ValidationError{Message: message, Args: args}
+}
+
+// scripts are the alphabets allowed with the script rule.
+var scripts = map[string]*unicode.RangeTable{
+	"latin":    unicode.Latin,
+	"cyrillic": unicode.Cyrillic,
+}
+
+// nameSeparators may be used in names along with the letters, e.g. "Анна-Мария" or "O'Neil".
+const nameSeparators = " -'’."
+
+// Validate checks the value against the rules of the validate tag of the field of the struct v.
+// The rules are separated by commas:
+//
+//	min=N, max=N      length of a string in characters
+//	script=a|b        letters of a single one of the scripts and the name separators only
+//	text              no control characters except line breaks
+//	past              a date not in the future
+//	minage=N, maxage=N age in years for a birth date
+func Validate(v any, field string, value any) error {
+	structField, ok := reflect.TypeOf(v).Elem().FieldByName(field)
+	if !ok {
+		return fmt.Errorf("unknown field %s", field)
+	}
+	tag := structField.Tag.Get("validate")
+	if tag == "" {
+		return nil
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		var err error
+		switch value := value.(type) {
+		case string:
+			err = validateString(name, arg, value)
+		case time.Time:
+			err = validateDate(name, arg, value, time.Now())
+		default:
+			err = fmt.Errorf("field %s of type %T can't be validated", field, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateString(rule, arg, value string) error {
+	length := utf8.RuneCountInString(value)
+	switch rule {
+	case "min":
+		n, _ := strconv.Atoi(arg)
+		if length == 0 && n > 0 {
+			return invalid("validation.empty")
+		}
+		if length < n {
+			return invalid("validation.min", n)
+		}
+	case "max":
+		n, _ := strconv.Atoi(arg)
+		if length > n {
+			return invalid("validation.max", n)
+		}
+	case "script":
+		return validateScript(strings.Split(arg, "|"), value)
+	case "text":
+		for _, r := range value {
+			if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+				return invalid("validation.text")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown validation rule %s", rule)
+	}
+	return nil
+}
+
+func validateScript(allowed []string, value string) error {
+	used := ""
+	for _, r := range value {
+		if strings.ContainsRune(nameSeparators, r) {
+			continue
+		}
+		script := ""
+		for _, name := range allowed {
+			if unicode.IsLetter(r) && unicode.Is(scripts[name], r) {
+				script = name
+				break
+			}
+		}
+		if script == "" || (used != "" && used != script) {
+			return invalid("validation.script")
+		}
+		used = script
+	}
+	if used == "" && value != "" {
+		return invalid("validation.script")
+	}
+	return nil
+}
+
+func validateDate(rule, arg string, value, now time.Time) error {
+	switch rule {
+	case "past":
+		if value.After(now) {
+			return invalid("validation.future")
+		}
+	case "minage":
+		n, _ := strconv.Atoi(arg)
+		if value.AddDate(n, 0, 0).After(now) {
+			return invalid("validation.min_age", n)
+		}
+	case "maxage":
+		n, _ := strconv.Atoi(arg)
+		if value.AddDate(n, 0, 0).Before(now) {
+			return invalid("validation.max_age", n)
+		}
+	default:
+		return fmt.Errorf("unknown validation rule %s", rule)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package objects
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tgbot-numerologist/i18n"
+)
+
+type validated struct {
+	Name        string    `validate:"min=2,max=5,script=latin|cyrillic"`
+	Bio         string    `validate:"max=10,text"`
+	BirthDate   time.Time `validate:"past,minage=5,maxage=120"`
+	Plain       string
+	UnknownRule string    `validate:"upper"`
+	UnknownDate time.Time `validate:"weekday"`
+	Count       int       `validate:"min=1"`
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		field   string
+		value   any
+		message string
+		args    []any
+		// other is set for the errors of the rules themselves, not of the value.
+		other bool
+	}{
+		{"min", "Name", "А", "validation.min", []any{2}, false},
+		{"min empty", "Name", "", "validation.empty", nil, false},
+		{"min in characters", "Name", "Ян", "", nil, false},
+		{"max", "Name", "Александр", "validation.max", []any{5}, false},
+		{"max in characters", "Name", "Алёна", "", nil, false},
+		{"latin", "Name", "D'Arc", "", nil, false},
+		{"cyrillic with separators", "Name", "А-Я я", "", nil, false},
+		{"mixed scripts", "Name", "Anна", "validation.script", nil, false},
+		{"digits", "Name", "Ann1", "validation.script", nil, false},
+		{"separators only", "Name", "-- ", "validation.script", nil, false},
+		{"other script", "Name", "Ἀλφα", "validation.script", nil, false},
+		{"text", "Bio", "line\nline\t", "", nil, false},
+		{"text control character", "Bio", "bell\a", "validation.text", nil, false},
+		{"text max", "Bio", strings.Repeat("a", 11), "validation.max", []any{10}, false},
+		{"past", "BirthDate", now.AddDate(-30, 0, 0), "", nil, false},
+		{"future", "BirthDate", now.Add(time.Hour), "validation.future", nil, false},
+		{"minage", "BirthDate", now.AddDate(-4, 0, 0), "validation.min_age", []any{5}, false},
+		{"maxage", "BirthDate", now.AddDate(-121, 0, 0), "validation.max_age", []any{120}, false},
+		{"no rules", "Plain", "\a", "", nil, false},
+		{"unknown string rule", "UnknownRule", "a", "", nil, true},
+		{"unknown date rule", "UnknownDate", now, "", nil, true},
+		{"unsupported type", "Count", 1, "", nil, true},
+		{"unknown field", "Missing", "a", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&validated{}, tt.field, tt.value)
+			var validationErr *ValidationError
+			switch {
+			case tt.other:
+				if err == nil || errors.As(err, &validationErr) {
+					t.Fatalf("Validate = %v, want a rule error", err)
+				}
+			case tt.message == "":
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+			case !errors.As(err, &validationErr):
+				t.Fatalf("Validate = %v, want %s", err, tt.message)
+			case validationErr.Message != tt.message || !reflect.DeepEqual(validationErr.Args, tt.args):
+				t.Fatalf("Validate = %s %v, want %s %v", validationErr.Message, validationErr.Args, tt.message, tt.args)
+			}
+		})
+	}
+}
+
+// TestProfileValidationMessages checks the errors the profile editing shows to the user:
+// they are *ValidationError with a message of the catalogs formatted with the args.
+func TestProfileValidationMessages(t *testing.T) {
+	tests := []struct {
+		field, text, message string
+	}{
+		{"Name", " ", "validation.empty"},
+		{"Name", strings.Repeat("а", 65), "validation.max"},
+		{"Name", "Anна", "validation.script"},
+		{"Surname", "Smith2", "validation.script"},
+		{"Bio", "\x00", "validation.text"},
+		{"WorkPlace", strings.Repeat("a", 201), "validation.max"},
+		{"BirthDate", time.Now().AddDate(1, 0, 0).Format("02.01.2006"), "validation.future"},
+		{"BirthDate", time.Now().AddDate(-2, 0, 0).Format("02.01.2006"), "validation.min_age"},
+		{"BirthDate", "01.01.1890", "validation.max_age"},
+	}
+	for _, tt := range tests {
+		field := ProfileField{Field: tt.field}
+		profile := &Profile{}
+		err := field.Set(profile, tt.text)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Message != tt.message {
+			t.Errorf("Set %s to %q = %v, want %s", tt.field, tt.text, err, tt.message)
+			continue
+		}
+		if field.Get(profile) != "" {
+			t.Errorf("Set %s to %q changed the profile", tt.field, tt.text)
+		}
+		for _, lang := range []string{i18n.English, i18n.Russian} {
+			msg := i18n.T(lang, validationErr.Message, validationErr.Args...)
+			if msg == validationErr.Message || strings.Contains(msg, "%!") {
+				t.Errorf("%s message of %s: %q", lang, validationErr.Message, msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package places looks up the cities of birth in the dataset bundled with the bot,
+// so no geocoding service is needed. The dataset has only the large cities of Russia
+// and the neighbouring countries and the capitals, a place missing from it is entered
+// with its time zone, see ParseTimezone.
+package places
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/i18n"
+)
+
+//go:embed cities.csv
+var citiesCSV string
+
+// City is a place of birth with its coordinates and IANA time zone.
+// The places entered by the user have no country and coordinates.
+type City struct {
+	NameRU    string  `json:"name_ru"`
+	NameEN    string  `json:"name_en"`
+	CountryRU string  `json:"country_ru"`
+	CountryEN string  `json:"country_en"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+}
+
+// Name returns the city and the country in the language, e.g. "Москва, Россия".
+func (c City) Name(lang string) string {
+	name, country := c.NameRU, c.CountryRU
+	if lang == i18n.English {
+		name, country = c.NameEN, c.CountryEN
+	}
+	if country == "" {
+		return name
+	}
+	return name + ", " + country
+}
+
+// String is used in the profile for the AI.
+func (c City) String() string {
+	if c.CountryEN == "" {
+		return fmt.Sprintf("%s (%s)", c.NameEN, c.Timezone)
+	}
+	return fmt.Sprintf("%s, %s (%.4f, %.4f, %s)", c.NameEN, c.CountryEN, c.Latitude, c.Longitude, c.Timezone)
+}
+
+// utcOffset matches the whole hour offsets such as "UTC+3", "GMT-05:00" or "+3".
+var utcOffset = regexp.MustCompile(`(?i)^(?:utc|gmt)?\s*([+-])\s*(\d{1,2})(?::?00)?$`)
+
+// ParseTimezone returns the IANA time zone for an IANA name, e.g. "Europe/Moscow",
+// or an offset from UTC in whole hours, e.g. "UTC+3", which becomes "Etc/GMT-3".
+func ParseTimezone(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	switch strings.ToUpper(s) {
+	case "UTC", "GMT":
+		return "UTC", true
+	}
+	if match := utcOffset.FindStringSubmatch(s); match != nil {
+		hours, _ := strconv.Atoi(match[2])
+		if hours == 0 {
+			return "UTC", true
+		}
+		// The signs of the Etc zones are inverted: Etc/GMT-3 is three hours ahead of UTC.
+		sign := "-"
+		if match[1] == "-" {
+			sign = "+"
+		}
+		s = "Etc/GMT" + sign + strconv.Itoa(hours)
+	}
+	if s == "" || s == "Local" {
+		return "", false
+	}
+	if _, err := time.LoadLocation(s); err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+var cities []City
+
+// index maps the normalized names and aliases of the cities to their positions in cities.
+var index = map[string][]int{}
+
+func init() {
+	records, err := csv.NewReader(strings.NewReader(citiesCSV)).ReadAll()
+	if err != nil {
+		panic("places: " + err.Error())
+	}
+	for _, record := range records[1:] {
+		latitude, err1 := strconv.ParseFloat(record[4], 64)
+		longitude, err2 := strconv.ParseFloat(record[5], 64)
+		if err1 != nil || err2 != nil {
+			panic("places: wrong coordinates of " + record[1])
+		}
+		cities = append(cities, City{
+			NameRU: record[0], NameEN: record[1], CountryRU: record[2], CountryEN: record[3],
+			Latitude: latitude, Longitude: longitude, Timezone: record[6],
+		})
+		names := []string{record[0], record[1]}
+		if record[7] != "" {
+			names = append(names, strings.Split(record[7], "|")...)
+		}
+		for _, name := range names {
+			key := normalize(name)
+			index[key] = append(index[key], len(cities)-1)
+		}
+	}
+}
+
+// normalize makes "Ростов-на-Дону", "ростов на дону" and "РОСТОВ-НА-ДОНУ" the same.
+func normalize(s string) string {
+	s = strings.NewReplacer("-", " ", "ё", "е").Replace(strings.ToLower(s))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Find returns the cities with the name, the name may be followed by the country after a comma,
+// e.g. "Брест, Беларусь". Several cities are returned when the name is ambiguous.
+func Find(query string) []City {
+	name, country, _ := strings.Cut(query, ",")
+	country = normalize(country)
+	var found []City
+	for _, i := range index[normalize(name)] {
+		city := cities[i]
+		if country != "" && normalize(city.CountryRU) != country && normalize(city.CountryEN) != country {
+			continue
+		}
+		found = append(found, city)
+	}
+	return found
+}
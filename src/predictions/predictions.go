@@ -0,0 +1,169 @@
+package predictions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tgbot-numerologist/ai"
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/numerology"
+	"tgbot-numerologist/objects"
+)
+
+// Input is everything a prediction can be built from.
+type Input struct {
+	Profile *objects.Profile
+	Chart   numerology.Chart
+	Now     time.Time
+	// Extra is the value asked from the user for the types with InputPrompt, e.g. a house number.
+	Extra string
+	// Partner is set for the types with NeedsPartner.
+	Partner      *objects.Partner
+	PartnerChart numerology.Chart
+}
+
+// Type is a kind of prediction offered in the /predictions menu.
+type Type struct {
+	ID string
+	// Cost is the quota taken for the prediction.
+	Cost int64
+	// InputPrompt is the message ID of the question asked before the prediction
+	// when the type needs additional data from the user.
+	InputPrompt string
+	// ParseInput validates the answer to InputPrompt.
+	ParseInput func(text string) (string, error)
+	// NeedsPartner types are made for the user and a saved partner.
+	NeedsPartner bool
+	// Numbers returns the type specific numbers for the prompt.
+	Numbers func(in Input) (string, error)
+}
+
+var ErrWrongInput = errors.New("wrong input")
+
+var Catalog = []Type{
+	{
+		ID:   objects.PredictionGeneral,
+		Cost: 1,
+	},
+	{
+		ID:   "daily",
+		Cost: 1,
+		Numbers: func(in Input) (string, error) {
+			return i18n.T(in.Profile.Language, "prompt.daily",
+				in.Now.Format("02.01.2006"), numerology.PersonalDay(in.Profile.BirthDate, in.Now)), nil
+		},
+	},
+	{
+		ID:   "personal_year",
+		Cost: 1,
+		Numbers: func(in Input) (string, error) {
+			return i18n.T(in.Profile.Language, "prompt.personal_year",
+				in.Now.Year(), numerology.PersonalYear(in.Profile.BirthDate, in.Now), numerology.PersonalMonth(in.Profile.BirthDate, in.Now)), nil
+		},
+	},
+	{
+		ID:           "compatibility",
+		Cost:         2,
+		NeedsPartner: true,
+		Numbers: func(in Input) (string, error) {
+			if in.Partner == nil {
+				return "", fmt.Errorf("%w: partner is not chosen", ErrWrongInput)
+			}
+			lang := in.Profile.Language
+			partner := i18n.T(lang, "prompt.partner",
+				in.Partner.Name, in.Partner.Surname, in.Partner.BirthDate.Format("02.01.2006"))
+			return partner + in.PartnerChart.Format(lang, i18n.T(lang, "prompt.partner_chart")) +
+				i18n.T(lang, "prompt.compatibility", numerology.Compatibility(in.Chart, in.PartnerChart)), nil
+		},
+	},
+	{
+		ID:   "career",
+		Cost: 1,
+	},
+	{
+		ID:   "name",
+		Cost: 1,
+		Numbers: func(in Input) (string, error) {
+			if in.Chart.Expression == 0 {
+				return "", fmt.Errorf("%w: name has no letters", ErrWrongInput)
+			}
+			return "", nil
+		},
+	},
+	{
+		ID:          "house",
+		Cost:        1,
+		InputPrompt: "prediction.house.input",
+		ParseInput: func(text string) (string, error) {
+			text = strings.TrimSpace(text)
+			if text == "" || len([]rune(text)) > 16 || numerology.Address(text) == 0 {
+				return "", ErrWrongInput
+			}
+			return text, nil
+		},
+		Numbers: func(in Input) (string, error) {
+			return i18n.T(in.Profile.Language, "prompt.house", in.Extra, numerology.Address(in.Extra)), nil
+		},
+	},
+	{
+		ID:   "lucky_dates",
+		Cost: 1,
+		Numbers: func(in Input) (string, error) {
+			dates := numerology.LuckyDates(in.Profile.BirthDate, in.Now, 30)
+			var formatted []string
+			for _, date := range dates {
+				formatted = append(formatted, date.Format("02.01.2006"))
+			}
+			if len(formatted) == 0 {
+				formatted = append(formatted, i18n.T(in.Profile.Language, "prompt.no_dates"))
+			}
+			return i18n.T(in.Profile.Language, "prompt.lucky_dates", strings.Join(formatted, ", ")), nil
+		},
+	},
+}
+
+func Get(id string) (Type, bool) {
+	for _, t := range Catalog {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Type{}, false
+}
+
+// Title returns the title of the type shown in the menu and the history.
+func Title(lang, id string) string {
+	return i18n.T(lang, "prediction."+id)
+}
+
+func (t Type) Title(lang string) string {
+	return Title(lang, t.ID)
+}
+
+// Task returns the instruction of the type appended to the base system prompt.
+func (t Type) Task(lang string) string {
+	return i18n.T(lang, "prompt.task."+t.ID)
+}
+
+// Messages builds the conversation for the model from the profile, the chart and the type specific numbers.
+func (t Type) Messages(in Input) ([]ai.Message, error) {
+	profileStr, err := in.Profile.ProfileAIMessage()
+	if err != nil {
+		return nil, err
+	}
+	lang := in.Profile.Language
+	content := profileStr + "\n" + in.Chart.AIMessage(lang)
+	if t.Numbers != nil {
+		numbers, err := t.Numbers(in)
+		if err != nil {
+			return nil, err
+		}
+		content += numbers
+	}
+	return []ai.Message{
+		{Role: ai.RoleSystem, Content: i18n.T(lang, "prompt.base") + t.Task(lang) + " " + i18n.T(lang, "prompt.answer_language")},
+		{Role: ai.RoleUser, Content: content},
+	}, nil
+}
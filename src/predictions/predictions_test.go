@@ -0,0 +1,59 @@
+package predictions
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"tgbot-numerologist/i18n"
+	"tgbot-numerologist/numerology"
+	"tgbot-numerologist/objects"
+)
+
+func hasCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMessagesLanguage(t *testing.T) {
+	birthDate := time.Date(1990, time.July, 15, 0, 0, 0, 0, time.UTC)
+	partner := objects.Partner{Name: "Jane", Surname: "Doe", BirthDate: time.Date(1992, time.March, 3, 0, 0, 0, 0, time.UTC)}
+	partnerChart, err := numerology.CalculatePartner(partner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lang := range []string{i18n.English, i18n.Russian} {
+		profile := &objects.Profile{Language: lang, Name: "John", Surname: "Smith", BirthDate: birthDate}
+		chart, err := numerology.Calculate(profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := Input{
+			Profile:      profile,
+			Chart:        chart,
+			Now:          time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			Extra:        "12",
+			Partner:      &partner,
+			PartnerChart: partnerChart,
+		}
+		for _, typ := range Catalog {
+			messages, err := typ.Messages(in)
+			if err != nil {
+				t.Fatalf("%s %s: %v", lang, typ.ID, err)
+			}
+			for _, message := range messages {
+				if got := hasCyrillic(message.Content); got != (lang == i18n.Russian) {
+					t.Errorf("%s %s %s message has Cyrillic %v:\n%s", lang, typ.ID, message.Role, got, message.Content)
+				}
+			}
+			if !strings.HasSuffix(messages[0].Content, i18n.T(lang, "prompt.answer_language")) {
+				t.Errorf("%s %s system prompt doesn't ask for the answer language", lang, typ.ID)
+			}
+		}
+	}
+}
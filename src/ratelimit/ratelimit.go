@@ -0,0 +1,114 @@
+// Package ratelimit paces the requests to the Telegram API and the actions of the users with token buckets.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket refilled with rate tokens per second up to burst tokens.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// refill adds the tokens earned since the last call, l.mu is held.
+func (l *Limiter) refill(now time.Time) {
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// reserve takes a token and returns how long to wait until it is available.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(now)
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	delay := l.reserve(time.Now())
+	if delay == 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Allow takes a token when it is available now and reports whether it was taken.
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Keyed keeps a separate bucket for every key, e.g. for every chat.
+type Keyed struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	limiters map[int64]*Limiter
+}
+
+func NewKeyed(rate float64, burst int) *Keyed {
+	return &Keyed{rate: rate, burst: burst, limiters: make(map[int64]*Limiter)}
+}
+
+// keyedPruneSize is the number of buckets after which the full ones are dropped.
+const keyedPruneSize = 10000
+
+func (k *Keyed) limiter(key int64, now time.Time) *Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if l, ok := k.limiters[key]; ok {
+		return l
+	}
+	if len(k.limiters) >= keyedPruneSize {
+		// A bucket refilled to the burst is the same as a new one.
+		idle := time.Duration(float64(k.burst) / k.rate * float64(time.Second))
+		for key, l := range k.limiters {
+			l.mu.Lock()
+			if now.Sub(l.last) > idle {
+				delete(k.limiters, key)
+			}
+			l.mu.Unlock()
+		}
+	}
+	l := New(k.rate, k.burst)
+	k.limiters[key] = l
+	return l
+}
+
+// Wait blocks until a token of the key is available or ctx is done.
+func (k *Keyed) Wait(ctx context.Context, key int64) error {
+	return k.limiter(key, time.Now()).Wait(ctx)
+}
+
+// Allow takes a token of the key when it is available now and reports whether it was taken.
+func (k *Keyed) Allow(key int64) bool {
+	return k.limiter(key, time.Now()).Allow()
+}
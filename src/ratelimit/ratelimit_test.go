@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReserve(t *testing.T) {
+	type take struct {
+		at   time.Duration
+		wait time.Duration
+	}
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		takes []take
+	}{
+		{"burst", 2, 3, []take{{0, 0}, {0, 0}, {0, 0}, {0, 500 * time.Millisecond}, {0, time.Second}}},
+		{"refill", 2, 1, []take{{0, 0}, {500 * time.Millisecond, 0}, {time.Second, 0}, {time.Second, 500 * time.Millisecond}}},
+		{"refill up to burst", 1, 2, []take{{0, 0}, {0, 0}, {time.Hour, 0}, {time.Hour, 0}, {time.Hour, time.Second}}},
+		{"debt is paid first", 1, 1, []take{{0, 0}, {0, time.Second}, {0, 2 * time.Second}, {time.Second, 2 * time.Second}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(tt.rate, tt.burst)
+			start := l.last
+			for i, take := range tt.takes {
+				if got := l.reserve(start.Add(take.at)); got != take.wait {
+					t.Errorf("take %d at %s waits %s, want %s", i, take.at, got, take.wait)
+				}
+			}
+		})
+	}
+}
+
+func TestAllow(t *testing.T) {
+	l := New(1.0/3600, 2)
+	for i, want := range []bool{true, true, false, false} {
+		if got := l.Allow(); got != want {
+			t.Errorf("Allow %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := New(1.0/3600, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait with a token: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Error("Wait without a token returned before the deadline")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := New(1, 1).Wait(ctx); err == nil {
+		t.Error("Wait with a cancelled context succeeded")
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	k := NewKeyed(1.0/3600, 1)
+	tests := []struct {
+		key  int64
+		want bool
+	}{
+		{1, true},
+		{1, false},
+		{2, true},
+		{2, false},
+		{1, false},
+	}
+	for i, tt := range tests {
+		if got := k.Allow(tt.key); got != tt.want {
+			t.Errorf("step %d Allow(%d) = %v, want %v", i, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestKeyedPrune(t *testing.T) {
+	k := NewKeyed(1, 1)
+	now := time.Now()
+	for key := range int64(keyedPruneSize) {
+		k.limiter(key, now)
+	}
+	// The buckets are refilled after a second, all of them are dropped when the next key is added.
+	k.limiter(keyedPruneSize, now.Add(2*time.Second))
+	if len(k.limiters) != 1 {
+		t.Errorf("%d buckets are kept", len(k.limiters))
+	}
+}
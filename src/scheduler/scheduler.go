@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"tgbot-numerologist/utils"
+)
+
+// Job is run on every tick of the scheduler with the time of the tick.
+type Job func(ctx context.Context, now time.Time)
+
+// Scheduler runs the job periodically at the start of every interval, e.g. every minute.
+// A tick is skipped while the previous run is still in progress.
+type Scheduler struct {
+	interval time.Duration
+	job      Job
+	done     chan struct{}
+}
+
+func New(interval time.Duration, job Job) *Scheduler {
+	return &Scheduler{interval: interval, job: job, done: make(chan struct{})}
+}
+
+// Start runs the job in background until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	go s.run(ctx)
+}
+
+// Wait blocks until the current run finishes after ctx passed to Start is cancelled
+// or returns the error of ctx when it is done first.
+func (s *Scheduler) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(s.interval).Add(s.interval).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case now = <-timer.C:
+		}
+		s.runJob(ctx, now)
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.Log("panic in scheduled job: %v", r)
+		}
+	}()
+	s.job(ctx, now)
+}
@@ -27,5 +27,9 @@ func Log(format string, v ...any) {
 }
 
 func CloseLogger() {
+	if logFile == nil {
+		return
+	}
+	logFile.Sync()
 	logFile.Close()
 }